@@ -0,0 +1,184 @@
+package terminator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Audited actions in addition to the stop stage actions (see StageAction).
+const (
+	AuditSignal      = "signal"
+	AuditMessage     = "message"
+	AuditKill        = "kill"
+	AuditCloseWindow = "close_window"
+)
+
+// AuditRecord is a record of a single action performed on a process.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// ActorPID is PID of the current process.
+	ActorPID int `json:"actor_pid"`
+	// ActorUID is user ID of the current process, -1 on Windows.
+	ActorUID int `json:"actor_uid"`
+	// Target is identity of the process acted upon, captured before the action. Only PID is set if the identity could
+	// not be read.
+	Target ProcIdentity `json:"target"`
+	// Action is one of AuditSignal, AuditMessage, AuditKill, AuditCloseWindow or an action of a stop stage.
+	Action string `json:"action"`
+	// Signal is the signal sent by AuditSignal action.
+	Signal syscall.Signal `json:"signal,omitempty"`
+	// Message is the message written by AuditMessage action, redacted if AuditOptions.RedactMessages is set.
+	Message string `json:"message,omitempty"`
+	// Window is the handle of the window closed by AuditCloseWindow action.
+	Window uintptr `json:"window,omitempty"`
+	// Stage is a number of the stop stage (starting from 1) the action was performed by, or 0 if it was performed
+	// outside of a stop.
+	Stage int `json:"stage,omitempty"`
+	// Error is the error the action failed with. Empty if the action succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives audit records. Implementations must be safe for concurrent use.
+type AuditSink interface {
+	Record(record AuditRecord)
+}
+
+// AuditFunc is a function implementing AuditSink interface.
+type AuditFunc func(record AuditRecord)
+
+// Record is used to implement AuditSink interface.
+func (f AuditFunc) Record(record AuditRecord) {
+	f(record)
+}
+
+// JSONLAuditSink writes audit records to a writer as JSON lines.
+type JSONLAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLAuditSink returns new JSONLAuditSink writing to `w`, e.g. a file opened with os.O_APPEND flag.
+func NewJSONLAuditSink(w io.Writer) *JSONLAuditSink {
+	return &JSONLAuditSink{w: w}
+}
+
+// Record is used to implement AuditSink interface. Write errors are ignored.
+func (s *JSONLAuditSink) Record(record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(append(line, '\n'))
+}
+
+// SlogAuditSink writes audit records to a structured logger.
+type SlogAuditSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogAuditSink returns new SlogAuditSink writing to `logger` with info level, or error level for failed actions.
+func NewSlogAuditSink(logger *slog.Logger) *SlogAuditSink {
+	return &SlogAuditSink{logger: logger}
+}
+
+// Record is used to implement AuditSink interface.
+func (s *SlogAuditSink) Record(record AuditRecord) {
+	attrs := []slog.Attr{
+		slog.Int("actor_pid", record.ActorPID),
+		slog.Int("actor_uid", record.ActorUID),
+		slog.Int("pid", record.Target.PID),
+		slog.Int64("create_time", record.Target.CreateTime),
+		slog.String("exe", record.Target.Exe),
+		slog.Any("cmdline", record.Target.Cmdline),
+		slog.String("action", record.Action),
+	}
+	if record.Signal != 0 {
+		attrs = append(attrs, slog.String("signal", record.Signal.String()))
+	}
+	if record.Message != "" {
+		attrs = append(attrs, slog.String("message", record.Message))
+	}
+	if record.Window != 0 {
+		attrs = append(attrs, slog.Any("window", record.Window))
+	}
+	if record.Stage != 0 {
+		attrs = append(attrs, slog.Int("stage", record.Stage))
+	}
+	level := slog.LevelInfo
+	if record.Error != "" {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", record.Error))
+	}
+	s.logger.LogAttrs(context.Background(), level, "terminator action", attrs...)
+}
+
+// AuditOptions configures the audit of actions performed on processes.
+type AuditOptions struct {
+	// Sink receives the audit records. Audit is disabled if nil.
+	Sink AuditSink
+	// RedactMessages is set to true to record the length of the messages instead of their content.
+	RedactMessages bool
+}
+
+var (
+	auditOptionsMu sync.RWMutex
+	auditOptions   AuditOptions
+)
+
+// SetAuditOptions replaces the audit options used by all operations with `opts`.
+//
+// Once set, signals sent, messages written, processes killed and windows closed by this package are recorded,
+// including the ones performed by stop stages.
+func SetAuditOptions(opts AuditOptions) {
+	auditOptionsMu.Lock()
+	defer auditOptionsMu.Unlock()
+	auditOptions = opts
+}
+
+// GetAuditOptions returns the audit options used by all operations.
+func GetAuditOptions() AuditOptions {
+	auditOptionsMu.RLock()
+	defer auditOptionsMu.RUnlock()
+	return auditOptions
+}
+
+// auditStageKey is a context key of the number of the stop stage being run.
+type auditStageKey struct{}
+
+// withAuditStage returns a copy of `ctx` with which the actions are recorded as performed by the stop stage `stage`.
+func withAuditStage(ctx context.Context, stage int) context.Context {
+	return context.WithValue(ctx, auditStageKey{}, stage)
+}
+
+// startAudit captures the identity of the process with PID `pid` using context `ctx` and returns a function recording
+// `record` with the outcome pointed by `errp`, meant to be deferred.
+func startAudit(ctx context.Context, pid int, record AuditRecord) func(errp *error) {
+	opts := GetAuditOptions()
+	if opts.Sink == nil {
+		return func(*error) {}
+	}
+	record.Time = time.Now()
+	record.Target, _ = processIdentity(ctx, pid)
+	record.Target.PID = pid
+	record.ActorPID = os.Getpid()
+	record.ActorUID = os.Getuid()
+	record.Stage, _ = ctx.Value(auditStageKey{}).(int)
+	if opts.RedactMessages && record.Message != "" {
+		record.Message = fmt.Sprintf("<redacted, %v bytes>", len(record.Message))
+	}
+	return func(errp *error) {
+		if *errp != nil {
+			record.Error = (*errp).Error()
+		}
+		opts.Sink.Record(record)
+	}
+}
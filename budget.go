@@ -0,0 +1,79 @@
+package terminator
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// stopBudget splits the deadline of a stop context across the stages of a policy.
+type stopBudget struct {
+	// deadline is the deadline of the stop context. Zero if the context has no deadline.
+	deadline time.Time
+	// total is the time left until the deadline at the start of the stop.
+	total time.Duration
+	// reserve is the time before the deadline kept for the kill stage.
+	reserve time.Duration
+	// hasKill is true if the policy has a kill stage.
+	hasKill bool
+}
+
+// newStopBudget returns a budget of stopping with `policy` using context `ctx`.
+func newStopBudget(ctx context.Context, policy Policy) stopBudget {
+	budget := stopBudget{
+		hasKill: lo.ContainsBy(policy.Stages, func(stage Stage) bool { return stage.Action == ActionKill }),
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return budget
+	}
+	budget.deadline = deadline
+	budget.total = max(time.Until(deadline), 0)
+	if budget.hasKill {
+		budget.reserve = policy.KillReserve
+		if budget.reserve <= 0 {
+			budget.reserve = DefaultKillReserve
+		}
+		// Do not let the reserve eat the whole budget if the deadline is close.
+		budget.reserve = min(budget.reserve, budget.total/2)
+	}
+	return budget
+}
+
+// end returns the time `stage` must finish by. Stages other than kill end before the kill reserve.
+func (b stopBudget) end(stage Stage) time.Time {
+	if stage.Action == ActionKill {
+		return b.deadline
+	}
+	return b.deadline.Add(-b.reserve)
+}
+
+// exhausted returns true if there is no time left to run `stage`.
+func (b stopBudget) exhausted(stage Stage) bool {
+	return !b.deadline.IsZero() && !time.Now().Before(b.end(stage))
+}
+
+// stageContext returns a copy of `ctx` which is done when `stage` runs out of time.
+func (b stopBudget) stageContext(ctx context.Context, stage Stage) (context.Context, context.CancelFunc) {
+	if b.deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.end(stage))
+}
+
+// grace returns the grace period of `stage` limited by the time left for it.
+func (b stopBudget) grace(stage Stage) time.Duration {
+	if b.deadline.IsZero() {
+		return stage.Grace
+	}
+	left := max(time.Until(b.end(stage)), 0)
+	switch stage.GraceMode {
+	case GraceProportional:
+		return min(time.Duration(float64(b.total)*stage.Share), left)
+	case GraceRemaining:
+		return left
+	default:
+		return min(stage.Grace, left)
+	}
+}
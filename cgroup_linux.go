@@ -0,0 +1,145 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+)
+
+// cgroupRoots are possible mount points of the unified cgroup (v2) hierarchy: pure v2 and hybrid modes.
+var cgroupRoots = []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"}
+
+// cgroupRoot returns mount point of the unified cgroup hierarchy or empty string if it is not mounted.
+func cgroupRoot() string {
+	root, _ := lo.Find(cgroupRoots, func(root string) bool {
+		_, err := os.Stat(filepath.Join(root, "cgroup.controllers"))
+		return err == nil
+	})
+	return root
+}
+
+// freezeCgroup freezes (or thaws if `freeze` is false) the cgroup v2 of the processes `pids` using context `ctx` and
+// waits until the state is reached.
+//
+// The cgroup is only used if all of the processes belong to it and it (with it's descendant cgroups) does not contain
+// any other processes. Returns false if the cgroup was not used.
+func freezeCgroup(ctx context.Context, pids []int, freeze bool) (bool, error) {
+	if len(pids) == 0 {
+		return false, nil
+	}
+	dir, err := procCgroup(pids[0])
+	if err != nil {
+		return false, nil
+	}
+	for _, pid := range pids[1:] {
+		if other, err := procCgroup(pid); err != nil || other != dir {
+			return false, nil
+		}
+	}
+	members, err := cgroupMembers(dir)
+	if err != nil || len(lo.Without(members, pids...)) != 0 {
+		return false, nil
+	}
+	if !freeze {
+		// Only thaw the cgroup if it was frozen.
+		state, err := os.ReadFile(filepath.Join(dir, "cgroup.freeze"))
+		if err != nil || strings.TrimSpace(string(state)) != "1" {
+			return false, nil
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.freeze"), []byte(lo.Ternary(freeze, "1", "0")), 0644); err != nil {
+		return false, errors.Wrapf(err, "Set freeze state of cgroup %v", dir)
+	}
+	return true, errors.Wrapf(waitForCgroupFrozen(ctx, dir, freeze), "Set freeze state of cgroup %v", dir)
+}
+
+// procCgroup returns path to the cgroup v2 directory of the process with PID `pid`.
+//
+// Returns error if the process is not in the unified hierarchy or is in the root cgroup, which can not be frozen.
+func procCgroup(pid int) (string, error) {
+	content, err := os.ReadFile(procPath(strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return "", errors.Wrapf(err, "Get cgroup of the process with PID %v", pid)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		path, ok := strings.CutPrefix(scanner.Text(), "0::")
+		if !ok {
+			continue
+		}
+		if path == "/" {
+			return "", errors.Newf("Get cgroup of the process with PID %v: Process is in the root cgroup", pid)
+		}
+		root := cgroupRoot()
+		if root == "" {
+			return "", errors.Newf("Get cgroup of the process with PID %v: Unified hierarchy is not mounted", pid)
+		}
+		dir := filepath.Join(root, path)
+		if _, err := os.Stat(filepath.Join(dir, "cgroup.freeze")); err != nil {
+			return "", errors.Wrapf(err, "Get cgroup of the process with PID %v", pid)
+		}
+		return dir, nil
+	}
+	return "", errors.Newf("Get cgroup of the process with PID %v: Process is not in the unified hierarchy", pid)
+}
+
+// cgroupMembers returns PID's of all processes of the cgroup with path `dir` and it's descendant cgroups.
+func cgroupMembers(dir string) ([]int, error) {
+	members := []int{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Name() != "cgroup.procs" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, field := range strings.Fields(string(content)) {
+			if pid, err := strconv.Atoi(field); err == nil {
+				members = append(members, pid)
+			}
+		}
+		return nil
+	})
+	return members, errors.Wrapf(err, "Get members of cgroup %v", dir)
+}
+
+// waitForCgroupFrozen returns nil when "frozen" field of cgroup.events of the cgroup with path `dir` matches `frozen`,
+// or error if it takes longer than suspendVerifyTimeout or `ctx` is done.
+func waitForCgroupFrozen(ctx context.Context, dir string, frozen bool) error {
+	ctx, cancel := context.WithTimeout(ctx, suspendVerifyTimeout)
+	defer cancel()
+
+	want := "frozen " + lo.Ternary(frozen, "1", "0")
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+	for {
+		events, err := os.ReadFile(filepath.Join(dir, "cgroup.events"))
+		if err != nil {
+			return errors.Wrap(err, "Wait for cgroup freeze state")
+		}
+		if lo.Contains(strings.Split(string(events), "\n"), want) {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "Wait for cgroup freeze state")
+		}
+	}
+}
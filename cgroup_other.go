@@ -0,0 +1,12 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+)
+
+// freezeCgroup returns false as cgroups are only available on Linux.
+func freezeCgroup(ctx context.Context, pids []int, freeze bool) (bool, error) {
+	return false, nil
+}
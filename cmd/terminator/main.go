@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/SCP002/terminator"
+	"github.com/samber/lo"
+)
+
+/*
+	Commands:
+	"find": Prints PID's of the processes matching a selector.
+	"stop": Stops the processes matching a selector gracefully.
+	"doctor": Prints which features are available on this host and how they are implemented (-json for JSON output).
+
+	Selectors (one of):
+	-pid: Process identifier.
+	-port: Local port owned by the process, with optional -proto ("tcp", "tcp4", "tcp6", "udp", "udp4", "udp6").
+	-path: File, directory or mount point held by the process.
+	-pidfile: Pidfile of the process. "stop" locks the pidfile and removes it after the process is stopped.
+
+	Flags of "stop":
+	-profile: Name of the stop profile to use instead of the default policy, or "auto" to detect it for each process.
+	-dry-run: Print the plan of the stop as JSON (or write it to -plan-file) instead of stopping.
+	-plan-file: Plan to write with -dry-run, or to execute instead of a selector.
+	-force: Skip the safety guards protecting critical processes.
+	-progress: Print progress events while stopping.
+
+	Interrupting "stop" (Ctrl + C) once kills the processes being stopped right away, interrupting it again aborts the
+	stop leaving the remaining processes running.
+*/
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	// "stop" handles interrupts itself, see runOperation.
+	ctx, cancel := context.WithCancel(context.Background())
+	if os.Args[1] != "stop" {
+		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt)
+	}
+	defer cancel()
+
+	guardOpts := terminator.GetGuardOptions()
+	guardOpts.Confirm = confirm
+	terminator.SetGuardOptions(guardOpts)
+
+	var err error
+	switch os.Args[1] {
+	case "find":
+		err = find(ctx, os.Args[2:])
+	case "stop":
+		err = stop(ctx, os.Args[2:])
+	case "doctor":
+		err = doctor(ctx, os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: terminator <find|stop|doctor> [flags]")
+	fmt.Fprintln(os.Stderr, "Run 'terminator <command> -h' to see flags of the command.")
+}
+
+// confirm asks the user whether to perform the operation `op` on processes `pids`.
+func confirm(op string, pids []int) bool {
+	fmt.Fprintf(os.Stderr, "About to %v %v processes: %v. Continue? [y/N] ", op, len(pids), pids)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// selectorFlags are the flags to build a selector from.
+type selectorFlags struct {
+	pid     int
+	port    int
+	proto   string
+	path    string
+	pidfile string
+}
+
+// register registers selector flags in the flag set `flags`.
+func (f *selectorFlags) register(flags *flag.FlagSet) {
+	flags.IntVar(&f.pid, "pid", 0, "Process identifier")
+	flags.IntVar(&f.port, "port", 0, "Local port owned by the process")
+	flags.StringVar(&f.proto, "proto", "", "Protocol of -port ('tcp', 'tcp4', 'tcp6', 'udp', 'udp4', 'udp6')")
+	flags.StringVar(&f.path, "path", "", "File, directory or mount point held by the process")
+	flags.StringVar(&f.pidfile, "pidfile", "", "Pidfile of the process")
+}
+
+// selector returns the selector specified by flags.
+func (f *selectorFlags) selector() (terminator.Selector, error) {
+	selectors := []terminator.Selector{}
+	if f.pid != 0 {
+		selectors = append(selectors, terminator.PIDSelector{f.pid})
+	}
+	if f.port != 0 {
+		selectors = append(selectors, terminator.PortSelector{Proto: f.proto, Port: f.port})
+	}
+	if f.path != "" {
+		selectors = append(selectors, terminator.PathSelector{Path: f.path})
+	}
+	if f.pidfile != "" {
+		selectors = append(selectors, terminator.PidfileSelector{Path: f.pidfile})
+	}
+	if len(selectors) != 1 {
+		return nil, fmt.Errorf("Exactly one of -pid, -port, -path or -pidfile is required")
+	}
+	return selectors[0], nil
+}
+
+// find prints PID's of the processes matching a selector specified by `args`.
+func find(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("find", flag.ExitOnError)
+	var sel selectorFlags
+	sel.register(flags)
+	_ = flags.Parse(args)
+
+	selector, err := sel.selector()
+	if err != nil {
+		return err
+	}
+	pids, err := selector.Select(ctx)
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		fmt.Println(pid)
+	}
+	return nil
+}
+
+// stop stops the processes matching a selector specified by `args`.
+func stop(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("stop", flag.ExitOnError)
+	var sel selectorFlags
+	sel.register(flags)
+	var profile, planFile string
+	var dryRun, force, progress bool
+	flags.StringVar(&profile, "profile", "",
+		"Name of the stop profile to use instead of the default policy, or 'auto' to detect it for each process")
+	flags.BoolVar(&dryRun, "dry-run", false, "Print the plan of the stop as JSON (or write it to -plan-file) instead of stopping")
+	flags.StringVar(&planFile, "plan-file", "", "Plan to write with -dry-run, or to execute instead of a selector")
+	flags.BoolVar(&force, "force", false, "Skip the safety guards protecting critical processes")
+	flags.BoolVar(&progress, "progress", false, "Print progress events while stopping")
+	_ = flags.Parse(args)
+
+	if force {
+		ctx = terminator.WithGuardOverride(ctx)
+	}
+	if planFile != "" && !dryRun {
+		return executePlanFile(ctx, planFile, progress)
+	}
+
+	selector, err := sel.selector()
+	if err != nil {
+		return err
+	}
+	policy := terminator.DefaultPolicy()
+	if profile == "auto" {
+		policy.AutoProfile = true
+	} else if profile != "" {
+		found, ok := terminator.LookupStopProfile(profile)
+		if !ok {
+			return fmt.Errorf("Unknown stop profile %q", profile)
+		}
+		policy = found.Policy
+	}
+
+	if dryRun {
+		plan, err := terminator.PlanSelectorWithContext(ctx, selector, policy)
+		if err != nil {
+			return err
+		}
+		content, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		if planFile != "" {
+			return os.WriteFile(planFile, append(content, '\n'), 0o644)
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	return runOperation(ctx, progress, func(ctx context.Context) *terminator.Operation {
+		if sel.pidfile != "" {
+			opts := terminator.PidfileOptions{RemoveStale: true}
+			return terminator.StartStopPidfile(ctx, sel.pidfile, opts, policy)
+		}
+		return terminator.StartStopSelector(ctx, selector, policy)
+	})
+}
+
+// runOperation runs the operation started by `start` using context `ctx` and prints it's results, and progress events
+// if `progress` is set.
+//
+// The first interrupt cancels the operation with Operation.Cancel, killing the processes being stopped, the second one
+// aborts it.
+func runOperation(ctx context.Context, progress bool, start func(ctx context.Context) *terminator.Operation) error {
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	op := start(ctx)
+	go func() {
+		select {
+		case <-interrupts:
+			fmt.Fprintln(os.Stderr, "Interrupted, killing the processes. Interrupt again to abort.")
+			op.Cancel()
+		case <-op.Done():
+			return
+		}
+		select {
+		case <-interrupts:
+			abort()
+		case <-op.Done():
+		}
+	}()
+
+	if progress {
+		for event := range op.Events() {
+			printEvent(event)
+		}
+	}
+	results, err := op.Wait()
+	for _, result := range results {
+		printStopResult(result)
+	}
+	return err
+}
+
+// printEvent prints the progress `event`.
+func printEvent(event terminator.ObserverEvent) {
+	elapsed := event.Elapsed.Round(time.Millisecond)
+	switch event.Kind {
+	case terminator.EventTargetResolved:
+		fmt.Printf("%v: %v\n", event.Target, event.PIDs)
+	case terminator.EventSignalSent:
+		fmt.Printf("PID %v: %v: stage %v sent %v\n", event.PID, elapsed, event.Stage, event.Signal)
+	case terminator.EventStopFailed:
+		fmt.Printf("PID %v: %v: %v\n", event.PID, elapsed, event.Err)
+	default:
+		fmt.Printf("PID %v: %v: %v, stage %v (%v)\n", event.PID, elapsed, event.Kind, event.Stage, event.Action)
+	}
+}
+
+// executePlanFile executes the plan read from the file with path `path`, printing progress events if `progress` is set.
+func executePlanFile(ctx context.Context, path string, progress bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var plan terminator.Plan
+	if err := json.Unmarshal(content, &plan); err != nil {
+		return fmt.Errorf("Parse plan file %v: %w", path, err)
+	}
+	return runOperation(ctx, progress, func(ctx context.Context) *terminator.Operation {
+		return terminator.StartExecutePlan(ctx, plan)
+	})
+}
+
+// doctor prints the report on the environment.
+func doctor(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	var asJSON bool
+	flags.BoolVar(&asJSON, "json", false, "Print the report as JSON")
+	_ = flags.Parse(args)
+
+	report := terminator.DoctorWithContext(ctx)
+	if asJSON {
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+
+	fmt.Printf("System:           %v/%v, kernel %v\n", report.OS, report.Arch, report.KernelVersion)
+	fmt.Printf("Root:             %v\n", report.Root)
+	fmt.Printf("Container:        %v\n", lo.CoalesceOrEmpty(report.Container, "not detected"))
+	fmt.Printf("PID namespace:    %v\n", lo.Ternary(report.PIDNamespace, "nested", "initial"))
+	fmt.Printf("TIOCSTI:          %v (legacy_tiocsti %v)\n", report.TIOCSTI, report.LegacyTIOCSTI)
+	fmt.Printf("Pidfd:            %v\n", report.Pidfd)
+	fmt.Printf("Cgroup:           v%v %v (delegated: %v)\n", report.CgroupVersion, report.Cgroup, report.CgroupDelegated)
+	fmt.Printf("Ptrace scope:     %v\n", report.PtraceScope)
+	fmt.Printf("Proc connector:   %v\n", report.ProcConnector)
+	fmt.Println("Backends:")
+	for _, backend := range report.Backends {
+		fmt.Printf("  %v: %v\n", backend.Feature, backend.Backend)
+	}
+	if len(report.Problems) != 0 {
+		fmt.Println("Problems:")
+		for _, problem := range report.Problems {
+			fmt.Printf("  %v\n", problem)
+		}
+	}
+	return nil
+}
+
+// printStopResult prints outcome of the stop `result`.
+func printStopResult(result terminator.StopResult) {
+	for i, stage := range result.Stages {
+		status := "done"
+		switch {
+		case stage.Skipped:
+			status = "skipped"
+		case stage.Err != nil:
+			status = fmt.Sprintf("failed: %v", stage.Err)
+		}
+		if stage.Signal != 0 {
+			fmt.Printf("PID %v: stage %v (%v %v): %v\n", result.PID, i+1, stage.Action, stage.Signal, status)
+		} else {
+			fmt.Printf("PID %v: stage %v (%v): %v\n", result.PID, i+1, stage.Action, status)
+		}
+	}
+	if result.Stopped {
+		fmt.Printf("PID %v: stopped, %v\n", result.PID, result.Exit)
+	} else {
+		fmt.Printf("PID %v: still running\n", result.PID)
+	}
+}
@@ -0,0 +1,173 @@
+package terminator
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// DefaultRedactEnv is a list of case-insensitive substrings of environment variable names whose values are redacted in
+// diagnostics bundles if DiagnosticsOptions.RedactEnv is nil.
+var DefaultRedactEnv = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "AUTH", "COOKIE"}
+
+// redactedValue replaces values of redacted environment variables.
+const redactedValue = "<redacted>"
+
+// DiagnosticsOptions are options of CaptureDiagnostics.
+type DiagnosticsOptions struct {
+	// Dir is a directory to write the bundle to. Temporary files folder is used if empty.
+	Dir string `json:"dir,omitempty"`
+	// Archive is set to true to write the bundle as .tar.gz archive instead of a directory.
+	Archive bool `json:"archive,omitempty"`
+	// Tree is set to true to capture all descendants of the process as well.
+	Tree bool `json:"tree,omitempty"`
+	// RedactEnv is a list of case-insensitive substrings of environment variable names whose values are redacted.
+	// DefaultRedactEnv is used if nil.
+	RedactEnv []string `json:"redact_env,omitempty"`
+	// GoroutineDump is set to true to send SIGQUIT to Go processes and capture the goroutine dump they print, if their
+	// standard error is redirected to a regular file. Note that the Go runtime exits after printing the dump. The signal
+	// is checked by the safety guards and recorded by the audit as any other.
+	GoroutineDump bool `json:"goroutine_dump,omitempty"`
+	// GoroutineDumpTimeout is how long to wait for the goroutine dump to be written. 5 seconds if 0.
+	GoroutineDumpTimeout time.Duration `json:"goroutine_dump_timeout,omitempty"`
+}
+
+// diagFile is a single file of a diagnostics bundle.
+type diagFile struct {
+	name string
+	data []byte
+}
+
+// CaptureDiagnostics is the same as CaptureDiagnosticsWithContext with background context.
+func CaptureDiagnostics(pid int, opts DiagnosticsOptions) (string, error) {
+	return CaptureDiagnosticsWithContext(context.Background(), pid, opts)
+}
+
+// CaptureDiagnosticsWithContext collects diagnostics of the process with PID `pid` using options `opts` and context
+// `ctx` and writes them to a bundle. Returns path to the bundle.
+//
+// The bundle contains a folder for each process with it's status, limits, open file descriptors, kernel stack, wait
+// channel, CPU and memory usage, command line, environment and goroutine dump if requested. Failures to collect
+// individual items do not interrupt the capture, they are listed in "errors.txt" files.
+//
+// Reading the kernel stack and environment of processes of other users requires root privilegies.
+func CaptureDiagnosticsWithContext(ctx context.Context, pid int, opts DiagnosticsOptions) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", errors.Wrapf(ctx.Err(), "Capture diagnostics of the process with PID %v", pid)
+	default:
+	}
+
+	pids := []int{pid}
+	if opts.Tree {
+		snap, err := NewProcSnapshot()
+		if err != nil {
+			return "", errors.Wrapf(err, "Capture diagnostics of the process with PID %v", pid)
+		}
+		pids = snap.FlatChildTree(pid, true)
+		// Root first.
+		slices.Reverse(pids)
+	}
+
+	files := []diagFile{}
+	for _, target := range pids {
+		procFiles, errs := collectDiagnostics(ctx, target, opts)
+		if len(procFiles) == 0 && target == pid && len(errs) > 0 {
+			return "", errors.Wrapf(errs[0], "Capture diagnostics of the process with PID %v", pid)
+		}
+		if len(errs) > 0 {
+			lines := []string{}
+			for _, err := range errs {
+				lines = append(lines, err.Error())
+			}
+			procFiles = append(procFiles, diagFile{name: "errors.txt", data: []byte(strings.Join(lines, "\n") + "\n")})
+		}
+		for _, file := range procFiles {
+			files = append(files, diagFile{name: filepath.Join(fmt.Sprint(target), file.name), data: file.data})
+		}
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	name := fmt.Sprintf("terminator_diagnostics_%v_%v", pid, time.Now().Format("20060102_150405.000"))
+	path, err := writeDiagBundle(filepath.Join(dir, name), opts.Archive, files)
+	if err != nil {
+		return "", errors.Wrapf(err, "Capture diagnostics of the process with PID %v", pid)
+	}
+	return path, nil
+}
+
+// writeDiagBundle writes `files` to a directory with path `path` or to `path`.tar.gz archive if `archive` is set to
+// true. Returns path of the written bundle.
+func writeDiagBundle(path string, archive bool, files []diagFile) (string, error) {
+	if !archive {
+		for _, file := range files {
+			filePath := filepath.Join(path, file.name)
+			if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+				return "", errors.Wrap(err, "Write diagnostics bundle")
+			}
+			if err := os.WriteFile(filePath, file.data, 0600); err != nil {
+				return "", errors.Wrap(err, "Write diagnostics bundle")
+			}
+		}
+		return path, nil
+	}
+
+	path += ".tar.gz"
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", errors.Wrap(err, "Write diagnostics bundle")
+	}
+	defer out.Close()
+	gzWriter := gzip.NewWriter(out)
+	tarWriter := tar.NewWriter(gzWriter)
+	now := time.Now()
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    filepath.ToSlash(filepath.Join(filepath.Base(strings.TrimSuffix(path, ".tar.gz")), file.name)),
+			Mode:    0600,
+			Size:    int64(len(file.data)),
+			ModTime: now,
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return "", errors.Wrap(err, "Write diagnostics bundle")
+		}
+		if _, err := tarWriter.Write(file.data); err != nil {
+			return "", errors.Wrap(err, "Write diagnostics bundle")
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return "", errors.Wrap(err, "Write diagnostics bundle")
+	}
+	if err := gzWriter.Close(); err != nil {
+		return "", errors.Wrap(err, "Write diagnostics bundle")
+	}
+	return path, errors.Wrap(out.Close(), "Write diagnostics bundle")
+}
+
+// redactEnv returns environment `env` in "KEY=value" form with values of variables whose names contain any of
+// `patterns` (case-insensitive) replaced.
+func redactEnv(env []string, patterns []string) []string {
+	out := make([]string, 0, len(env))
+	for _, entry := range env {
+		name, _, ok := strings.Cut(entry, "=")
+		upper := strings.ToUpper(name)
+		if ok && slices.ContainsFunc(patterns, func(pattern string) bool {
+			return strings.Contains(upper, strings.ToUpper(pattern))
+		}) {
+			entry = name + "=" + redactedValue
+		}
+		out = append(out, entry)
+	}
+	return out
+}
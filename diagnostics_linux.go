@@ -0,0 +1,160 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bytes"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/unix"
+)
+
+// collectDiagnostics returns diagnostics files of the process with PID `pid` collected using options `opts` and
+// context `ctx`, and errors of the items which failed to be collected.
+func collectDiagnostics(ctx context.Context, pid int, opts DiagnosticsOptions) ([]diagFile, []error) {
+	dir := strconv.Itoa(pid)
+	if _, err := os.Stat(procPath(dir)); err != nil {
+		return nil, []error{errors.Wrapf(err, "Collect diagnostics of the process with PID %v", pid)}
+	}
+
+	files := []diagFile{}
+	errs := []error{}
+	add := func(name string, data []byte, err error) {
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "Collect %v", name))
+			return
+		}
+		files = append(files, diagFile{name: name, data: data})
+	}
+	readProcFile := func(name string) ([]byte, error) {
+		return os.ReadFile(procPath(dir, name))
+	}
+
+	for _, name := range []string{"status", "stat", "limits", "stack", "wchan", "cgroup"} {
+		data, err := readProcFile(name)
+		add(name+".txt", data, err)
+	}
+
+	cmdline, err := readProcFile("cmdline")
+	add("cmdline.txt", bytes.ReplaceAll(bytes.TrimRight(cmdline, "\x00"), []byte{0}, []byte{'\n'}), err)
+
+	environ, err := readProcFile("environ")
+	if err == nil {
+		patterns := opts.RedactEnv
+		if patterns == nil {
+			patterns = DefaultRedactEnv
+		}
+		env := redactEnv(strings.Split(strings.TrimRight(string(environ), "\x00"), "\x00"), patterns)
+		environ = []byte(strings.Join(env, "\n") + "\n")
+	}
+	add("environ.txt", environ, err)
+
+	fds, err := readFds(pid)
+	add("fds.txt", fds, err)
+
+	usage, err := readUsage(pid)
+	add("usage.json", usage, err)
+
+	if opts.GoroutineDump {
+		if _, err := buildinfo.ReadFile(procPath(dir, "exe")); err == nil {
+			timeout := opts.GoroutineDumpTimeout
+			if timeout == 0 {
+				timeout = time.Second * 5
+			}
+			dump, err := captureGoroutineDump(ctx, pid, timeout)
+			add("goroutines.txt", dump, err)
+		}
+	}
+
+	return files, errs
+}
+
+// readFds returns list of open file descriptors of the process with PID `pid` and their targets, one per line.
+func readFds(pid int) ([]byte, error) {
+	dir := procPath(strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Read file descriptors")
+	}
+	var out bytes.Buffer
+	for _, entry := range entries {
+		target, err := os.Readlink(procPath(strconv.Itoa(pid), "fd", entry.Name()))
+		if err != nil {
+			// The descriptor was closed during the scan.
+			continue
+		}
+		fmt.Fprintf(&out, "%v -> %v\n", entry.Name(), target)
+	}
+	return out.Bytes(), nil
+}
+
+// readUsage returns CPU and memory usage of the process with PID `pid` in JSON format.
+func readUsage(pid int) ([]byte, error) {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return nil, errors.Wrap(err, "Read usage")
+	}
+	usage := map[string]any{}
+	if times, err := proc.Times(); err == nil {
+		usage["cpuTimes"] = times
+	}
+	if percent, err := proc.CPUPercent(); err == nil {
+		usage["cpuPercent"] = percent
+	}
+	if memory, err := proc.MemoryInfo(); err == nil {
+		usage["memory"] = memory
+	}
+	if threads, err := proc.NumThreads(); err == nil {
+		usage["threads"] = threads
+	}
+	if createTime, err := proc.CreateTime(); err == nil {
+		usage["createTime"] = time.UnixMilli(createTime)
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	return data, errors.Wrap(err, "Read usage")
+}
+
+// captureGoroutineDump sends SIGQUIT to the Go process with PID `pid` and returns what it wrote to the standard error
+// before exiting or `timeout` exceeded, using context `ctx`.
+//
+// The standard error of the process must be redirected to a regular file.
+func captureGoroutineDump(ctx context.Context, pid int, timeout time.Duration) ([]byte, error) {
+	stderr, err := os.Readlink(procPath(strconv.Itoa(pid), "fd", "2"))
+	if err != nil {
+		return nil, errors.Wrap(err, "Capture goroutine dump: Resolve standard error")
+	}
+	info, err := os.Stat(stderr)
+	if err != nil {
+		return nil, errors.Wrap(err, "Capture goroutine dump: Resolve standard error")
+	}
+	if !info.Mode().IsRegular() {
+		return nil, errors.Newf("Capture goroutine dump: Standard error %v is not a regular file", stderr)
+	}
+	offset := info.Size()
+
+	if err := SendSignalWithContext(ctx, pid, unix.SIGQUIT); err != nil {
+		return nil, errors.Wrap(err, "Capture goroutine dump")
+	}
+	// The Go runtime exits after the dump is written.
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	waitForProcStop(waitCtx, pid)
+
+	file, err := os.Open(stderr)
+	if err != nil {
+		return nil, errors.Wrap(err, "Capture goroutine dump: Open standard error")
+	}
+	defer file.Close()
+	dump, err := io.ReadAll(io.NewSectionReader(file, offset, 1<<30))
+	return dump, errors.Wrap(err, "Capture goroutine dump: Read standard error")
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+)
+
+// collectDiagnostics returns ErrNotSupported as diagnostics capture is only implemented on Linux.
+func collectDiagnostics(ctx context.Context, pid int, opts DiagnosticsOptions) ([]diagFile, []error) {
+	return nil, []error{errors.Wrapf(ErrNotSupported, "Collect diagnostics of the process with PID %v", pid)}
+}
@@ -0,0 +1,84 @@
+package terminator
+
+import (
+	"syscall"
+)
+
+// SignalAction is a disposition of a signal in a process.
+type SignalAction int
+
+const (
+	// SignalDefault indicates that the default action is taken on signal delivery.
+	SignalDefault SignalAction = iota
+	// SignalIgnored indicates that the signal is ignored.
+	SignalIgnored
+	// SignalCaught indicates that the signal is handled by the process.
+	SignalCaught
+)
+
+// String is used to implement fmt.Stringer interface.
+func (a SignalAction) String() string {
+	switch a {
+	case SignalIgnored:
+		return "ignored"
+	case SignalCaught:
+		return "caught"
+	default:
+		return "default"
+	}
+}
+
+// Disposition is a set of signal masks of a process.
+//
+// Bit N-1 of a mask corresponds to the signal N.
+type Disposition struct {
+	PID int
+	// Blocked is a mask of signals blocked by the main thread.
+	Blocked uint64
+	// Ignored is a mask of ignored signals.
+	Ignored uint64
+	// Caught is a mask of signals with a handler installed.
+	Caught uint64
+}
+
+// SignalState is a decoded disposition of a single signal.
+type SignalState struct {
+	Signal  syscall.Signal
+	Action  SignalAction
+	Blocked bool
+	// Terminates is set to true if the default action of the signal terminates the process.
+	Terminates bool
+}
+
+// Signal returns decoded disposition of the signal `sig`.
+func (d Disposition) Signal(sig syscall.Signal) SignalState {
+	state := SignalState{Signal: sig, Terminates: defaultTerminates(sig)}
+	if sig < 1 || sig > 64 {
+		return state
+	}
+	bit := uint64(1) << (sig - 1)
+	switch {
+	case d.Ignored&bit != 0:
+		state.Action = SignalIgnored
+	case d.Caught&bit != 0:
+		state.Action = SignalCaught
+	}
+	state.Blocked = d.Blocked&bit != 0
+	return state
+}
+
+// CanStop returns true if the signal `sig` has a chance to stop the process: it is either caught (the handler may exit)
+// or left at a terminating default action.
+//
+// Blocked signals are not taken into account as blocking is usually temporary.
+func (d Disposition) CanStop(sig syscall.Signal) bool {
+	state := d.Signal(sig)
+	switch state.Action {
+	case SignalCaught:
+		return true
+	case SignalDefault:
+		return state.Terminates
+	default:
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// SignalDisposition returns signal masks of the process with PID `pid` read from /proc/<pid>/status.
+func SignalDisposition(pid int) (Disposition, error) {
+	disp := Disposition{PID: pid}
+	status, err := os.ReadFile(procPath(strconv.Itoa(pid), "status"))
+	if err != nil {
+		return disp, errors.Wrapf(err, "Get signal disposition of the process with PID %v", pid)
+	}
+	masks := map[string]*uint64{"SigBlk": &disp.Blocked, "SigIgn": &disp.Ignored, "SigCgt": &disp.Caught}
+	found := 0
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		key, value, ok := bytes.Cut(scanner.Bytes(), []byte(":"))
+		if !ok {
+			continue
+		}
+		mask, ok := masks[string(key)]
+		if !ok {
+			continue
+		}
+		*mask, err = strconv.ParseUint(string(bytes.TrimSpace(value)), 16, 64)
+		if err != nil {
+			return disp, errors.Wrapf(err, "Get signal disposition of the process with PID %v: Parse %v", pid, key)
+		}
+		found++
+	}
+	if found != len(masks) {
+		return disp, errors.Newf("Get signal disposition of the process with PID %v: Signal masks not found", pid)
+	}
+	return disp, nil
+}
+
+// defaultTerminates returns true if the default action of the signal `sig` terminates the process.
+//
+// See signal(7).
+func defaultTerminates(sig syscall.Signal) bool {
+	switch sig {
+	case unix.SIGCHLD, unix.SIGCONT, unix.SIGSTOP, unix.SIGTSTP, unix.SIGTTIN, unix.SIGTTOU, unix.SIGURG, unix.SIGWINCH:
+		return false
+	default:
+		return sig >= 1 && sig <= 64
+	}
+}
+
+// chooseAutoSignal returns the first signal of `candidates` which can stop the process with PID `pid` and true, or false
+// if there is no such signal.
+//
+// If the signal disposition can not be read, the first candidate is returned.
+func chooseAutoSignal(pid int, candidates []syscall.Signal) (syscall.Signal, bool) {
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	disp, err := SignalDisposition(pid)
+	if err != nil {
+		return candidates[0], true
+	}
+	for _, sig := range candidates {
+		if disp.CanStop(sig) {
+			return sig, true
+		}
+	}
+	return 0, false
+}
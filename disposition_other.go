@@ -0,0 +1,28 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"syscall"
+
+	"github.com/cockroachdb/errors"
+)
+
+// SignalDisposition is only implemented on Linux, returns ErrNotSupported.
+func SignalDisposition(pid int) (Disposition, error) {
+	return Disposition{PID: pid}, errors.Wrapf(ErrNotSupported, "Get signal disposition of the process with PID %v", pid)
+}
+
+// defaultTerminates returns true as the signal disposition is not inspected on this platform.
+func defaultTerminates(sig syscall.Signal) bool {
+	return true
+}
+
+// chooseAutoSignal returns the first signal of `candidates` and true, or false if `candidates` is empty, as the signal
+// disposition can not be inspected on this platform.
+func chooseAutoSignal(pid int, candidates []syscall.Signal) (syscall.Signal, bool) {
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	return candidates[0], true
+}
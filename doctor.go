@@ -0,0 +1,60 @@
+package terminator
+
+import (
+	"context"
+	"runtime"
+)
+
+// DoctorReport describes the environment and the capabilities of the library on the current host.
+type DoctorReport struct {
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	KernelVersion string `json:"kernel_version"`
+	// Root is set to true if the current process runs as root (effective user ID 0).
+	Root bool `json:"root"`
+	// Container is a detected container runtime, e.g. "docker", "podman", "kubernetes", or empty string if not
+	// detected.
+	Container string `json:"container,omitempty"`
+	// PIDNamespace is set to true if the current process runs in a nested PID namespace.
+	PIDNamespace bool `json:"pid_namespace"`
+	// TIOCSTI is set to true if messages can be written to the consoles of the other processes, see SendMessage.
+	TIOCSTI bool `json:"tiocsti"`
+	// LegacyTIOCSTI is a value of dev.tty.legacy_tiocsti sysctl, or -1 if the kernel does not have it.
+	LegacyTIOCSTI int `json:"legacy_tiocsti"`
+	// Pidfd is set to true if the kernel supports process file descriptors (pidfd_open).
+	Pidfd bool `json:"pidfd"`
+	// CgroupVersion is 2 if the unified cgroup hierarchy is mounted (possibly in hybrid mode), 1 if only the legacy
+	// hierarchies are, or 0 if cgroups are not available.
+	CgroupVersion int `json:"cgroup_version"`
+	// Cgroup is a path to the cgroup v2 directory of the current process.
+	Cgroup string `json:"cgroup,omitempty"`
+	// CgroupDelegated is set to true if the current process can manage it's cgroup v2, e.g. freeze it.
+	CgroupDelegated bool `json:"cgroup_delegated"`
+	// PtraceScope is a value of kernel.yama.ptrace_scope sysctl, or -1 if Yama is not enabled.
+	PtraceScope int `json:"ptrace_scope"`
+	// ProcConnector is set to true if the process event stream is available, see ListenProcEvents.
+	ProcConnector bool `json:"proc_connector"`
+	// Backends lists the mechanisms the library uses for it's features on the current host.
+	Backends []DoctorBackend `json:"backends"`
+	// Problems lists errors which occurred while inspecting the environment.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// DoctorBackend is a mechanism the library uses for a feature on the current host.
+type DoctorBackend struct {
+	Feature string `json:"feature"`
+	Backend string `json:"backend"`
+}
+
+// Doctor is the same as DoctorWithContext with background context.
+func Doctor() DoctorReport {
+	return DoctorWithContext(context.Background())
+}
+
+// DoctorWithContext inspects the environment of the current process using context `ctx` and reports which features
+// of the library are available and how they are implemented on the current host.
+func DoctorWithContext(ctx context.Context) DoctorReport {
+	report := DoctorReport{OS: runtime.GOOS, Arch: runtime.GOARCH, LegacyTIOCSTI: -1, PtraceScope: -1}
+	inspectEnvironment(ctx, &report)
+	return report
+}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/sys/unix"
+)
+
+// inspectEnvironment fills `report` with the details of the Linux environment using context `ctx`.
+func inspectEnvironment(ctx context.Context, report *DoctorReport) {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err == nil {
+		report.KernelVersion = unix.ByteSliceToString(uname.Release[:])
+	} else {
+		report.Problems = append(report.Problems, "Get kernel version: "+err.Error())
+	}
+	report.Root = os.Geteuid() == 0
+	report.Container = detectContainer()
+
+	if status, err := readProcStatus("self"); err == nil {
+		// NSpid contains PID in each nested PID namespace, starting from the initial one.
+		report.PIDNamespace = len(strings.Fields(status["NSpid"])) > 1
+	} else {
+		report.Problems = append(report.Problems, err.Error())
+	}
+
+	if verdict, err := newPermissionVerdict(os.Getpid()); err == nil {
+		report.LegacyTIOCSTI = verdict.LegacyTIOCSTI
+		report.PtraceScope = verdict.PtraceScope
+		report.TIOCSTI = verdict.LegacyTIOCSTI != 0 && verdict.CapSysAdmin
+	} else {
+		report.Problems = append(report.Problems, err.Error())
+	}
+
+	if fd, err := unix.PidfdOpen(os.Getpid(), 0); err == nil {
+		report.Pidfd = true
+		_ = unix.Close(fd)
+	}
+
+	if root := cgroupRoot(); root != "" {
+		report.CgroupVersion = 2
+		if dir, err := procCgroup(os.Getpid()); err == nil {
+			report.Cgroup = dir
+			report.CgroupDelegated = unix.Access(filepath.Join(dir, "cgroup.procs"), unix.W_OK) == nil &&
+				unix.Access(filepath.Join(dir, "cgroup.freeze"), unix.W_OK) == nil
+		}
+	} else if _, err := os.Stat("/sys/fs/cgroup/freezer"); err == nil {
+		report.CgroupVersion = 1
+	}
+
+	if listener, err := ListenProcEvents(ctx); err == nil {
+		report.ProcConnector = true
+		_ = listener.Close()
+	} else {
+		report.Problems = append(report.Problems, err.Error())
+	}
+
+	report.Backends = []DoctorBackend{
+		{Feature: "SendSignal", Backend: "kill(2)"},
+		{Feature: "SendMessage", Backend: lo.Ternary(report.TIOCSTI, "TIOCSTI", "unavailable")},
+		{Feature: "WaitForProcStop", Backend: lo.Ternary(report.ProcConnector, "proc connector exit events", "polling")},
+		{Feature: "KillTree", Backend: lo.Ternary(report.ProcConnector,
+			"/proc snapshot, new children tracked with proc connector", "/proc snapshot")},
+		{Feature: "Suspend with SuspendOptions.Cgroup", Backend: lo.Ternary(report.CgroupDelegated,
+			"cgroup v2 freezer", "SIGSTOP")},
+		{Feature: "SignalDisposition", Backend: "/proc/<pid>/status"},
+	}
+}
+
+// detectContainer returns the name of the container runtime the current process runs in, or empty string.
+func detectContainer() string {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return "kubernetes"
+	}
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return "docker"
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return "podman"
+	}
+	// systemd-nspawn, LXC and others set "container" variable for the init process.
+	if environ, err := os.ReadFile(procPath("1", "environ")); err == nil {
+		for _, env := range bytes.Split(environ, []byte{0}) {
+			if name, ok := bytes.CutPrefix(env, []byte("container=")); ok {
+				return string(name)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	"github.com/samber/lo"
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+// inspectEnvironment fills `report` with the details of the environment using context `ctx`. Only the kernel version
+// and privileges are inspected on this platform.
+func inspectEnvironment(ctx context.Context, report *DoctorReport) {
+	version, err := host.KernelVersionWithContext(ctx)
+	if err != nil {
+		report.Problems = append(report.Problems, "Get kernel version: "+err.Error())
+	}
+	report.KernelVersion = version
+	report.Root = os.Geteuid() == 0
+	windows := runtime.GOOS == "windows"
+	report.TIOCSTI = !windows && report.Root
+
+	report.Backends = []DoctorBackend{
+		{Feature: "SendSignal", Backend: lo.Ternary(windows, "console control events via proxy process", "kill(2)")},
+		{Feature: "SendMessage", Backend: lo.Ternary(windows, "console input via proxy process",
+			lo.Ternary(report.TIOCSTI, "TIOCSTI", "unavailable"))},
+		{Feature: "WaitForProcStop", Backend: "polling"},
+		{Feature: "KillTree", Backend: "process snapshot"},
+		{Feature: "Suspend", Backend: lo.Ternary(windows, "NtSuspendProcess", "SIGSTOP")},
+	}
+}
@@ -0,0 +1,90 @@
+package terminator
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// drainInterval is how often connections are sampled during ActionDrain stage.
+const drainInterval = time.Millisecond * 500
+
+// DrainProgress is a sample of TCP sockets of a process taken during ActionDrain stage.
+type DrainProgress struct {
+	PID  int       `json:"pid"`
+	Time time.Time `json:"time"`
+	// Listening is a number of listening sockets.
+	Listening int `json:"listening"`
+	// Active is a number of connections which are not closed yet.
+	Active int `json:"active"`
+}
+
+// Drained returns true if the process has no listening sockets and no active connections.
+func (p DrainProgress) Drained() bool {
+	return p.Listening == 0 && p.Active == 0
+}
+
+// sampleConnections returns counts of listening sockets and active TCP connections of the process with PID `pid`.
+func sampleConnections(ctx context.Context, pid int) (DrainProgress, error) {
+	progress := DrainProgress{PID: pid, Time: time.Now()}
+	conns, err := net.ConnectionsPidWithContext(ctx, "tcp", int32(pid))
+	if err != nil {
+		return progress, errors.Wrapf(err, "Get connections of the process with PID %v", pid)
+	}
+	for _, conn := range conns {
+		switch conn.Status {
+		case "LISTEN":
+			progress.Listening++
+		case "CLOSE", "TIME_WAIT", "NONE", "":
+		default:
+			progress.Active++
+		}
+	}
+	return progress, nil
+}
+
+// waitForDrain returns true when the process with PID `pid` exits, or false when it has no listening sockets and no
+// active connections left or `ctx` is done first.
+//
+// Each connections sample is passed to `onProgress`, if not nil, and returned in the history slice.
+func waitForDrain(ctx context.Context, pid int, onProgress func(DrainProgress)) (bool, []DrainProgress) {
+	history := []DrainProgress{}
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return true, history
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	exited := procExitNotify(ctx, pid)
+
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+	for {
+		if procStopped(proc) {
+			return true, history
+		}
+		progress, err := sampleConnections(ctx, pid)
+		if err == nil {
+			history = append(history, progress)
+			if onProgress != nil {
+				onProgress(progress)
+			}
+			if progress.Drained() {
+				// Give the process a moment to exit on it's own after closing the last connection.
+				settleCtx, cancel := context.WithTimeout(ctx, drainInterval)
+				defer cancel()
+				return waitForProcStop(settleCtx, pid), history
+			}
+		}
+		select {
+		case <-exited:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return procStopped(proc), history
+		}
+	}
+}
@@ -18,6 +18,12 @@ func main() {
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
+	// Save terminal settings to restore them in case the child leaves the terminal in raw mode or without echo
+	state, err := terminator.SaveTermState("")
+	if err != nil {
+		fmt.Printf("SaveTermState failed with: %v\n", err)
+	}
+
 	if err := cmd.Start(); err != nil {
 		fmt.Printf("Start failed with: %v\n", err)
 	}
@@ -33,6 +39,11 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
 	defer cancel()
 	terminator.WaitForProcStop(ctx, cmd.Process.Pid)
+	if state != nil {
+		if err := state.Restore(); err != nil {
+			fmt.Printf("Restore failed with: %v\n", err)
+		}
+	}
 	fmt.Println("\nContinuing execution of caller")
 
 	fmt.Print("Press <Enter> to exit...")
@@ -0,0 +1,116 @@
+package terminator
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// ExitKind is a kind of process termination.
+type ExitKind string
+
+const (
+	// ExitUnknown indicates that the process is gone, but how it ended is unknown, e.g. because it was not a child of
+	// the current process and the exit event was not observed.
+	ExitUnknown ExitKind = "unknown"
+	// ExitExited indicates that the process exited by itself. ExitInfo.Code is set.
+	ExitExited ExitKind = "exited"
+	// ExitSignaled indicates that the process was terminated by a signal. ExitInfo.Signal is set.
+	//
+	// On Windows, indicates that the process was terminated by Ctrl + C or Ctrl + Break (STATUS_CONTROL_C_EXIT exit
+	// code), with ExitInfo.Signal set to SIGINT.
+	ExitSignaled ExitKind = "signaled"
+	// ExitCrashed indicates that the process crashed. On POSIX, it was terminated by a signal and dumped core,
+	// ExitInfo.Signal and ExitInfo.CoreDumped are set. On Windows, it exited with an NTSTATUS error code, e.g.
+	// STATUS_ACCESS_VIOLATION, ExitInfo.Code is set.
+	ExitCrashed ExitKind = "crashed"
+)
+
+// ExitSource is where an ExitInfo was obtained from.
+type ExitSource string
+
+const (
+	// ExitSourceNone indicates that no source of the exit status was available.
+	ExitSourceNone ExitSource = ""
+	// ExitSourceWait indicates the wait status of a child of the current process, read with waitid(2) without reaping
+	// the child.
+	ExitSourceWait ExitSource = "wait"
+	// ExitSourceProcConnector indicates the exit event of the Linux process events connector, see ListenProcEvents.
+	ExitSourceProcConnector ExitSource = "proc_connector"
+	// ExitSourceExitCode indicates the exit code of a Windows process.
+	ExitSourceExitCode ExitSource = "exit_code"
+	// ExitSourceProcessState indicates os.ProcessState, see ExitInfoFromProcessState.
+	ExitSourceProcessState ExitSource = "process_state"
+	// ExitSourceShell indicates a shell-style exit code, see ExitInfoFromShellCode.
+	ExitSourceShell ExitSource = "shell"
+)
+
+// ExitInfo describes how a process ended.
+//
+// For processes stopped with StopWithContext, the exit status is read from the wait status if the process is a child of
+// the current process, from the proc connector exit event on Linux (requires CAP_NET_ADMIN capability) or from the exit
+// code on Windows. Otherwise, Kind is ExitUnknown.
+type ExitInfo struct {
+	Kind ExitKind
+	// Code is the exit code for ExitExited, or the raw Windows exit code for ExitSignaled and ExitCrashed.
+	Code int
+	// Signal is the signal which terminated the process for ExitSignaled and ExitCrashed.
+	Signal syscall.Signal
+	// CoreDumped is set to true if the process dumped core.
+	CoreDumped bool
+	Source     ExitSource
+}
+
+// ShellCode returns the exit status of the process as reported by POSIX shells in $?: exit code if the process exited,
+// 128 + signal number if it was terminated by a signal, or -1 if unknown.
+func (e ExitInfo) ShellCode() int {
+	switch {
+	case e.Kind == ExitUnknown || e.Kind == "":
+		return -1
+	case e.Signal != 0:
+		return 128 + int(e.Signal)
+	default:
+		return e.Code
+	}
+}
+
+// Graceful returns true if the process exited with code 0 or was terminated by SIGINT, SIGTERM or SIGHUP, the signals
+// asking a process to stop (including Ctrl + C on Windows).
+func (e ExitInfo) Graceful() bool {
+	switch e.Kind {
+	case ExitExited:
+		return e.Code == 0
+	case ExitSignaled:
+		return e.Signal == syscall.SIGINT || e.Signal == syscall.SIGTERM || e.Signal == syscall.SIGHUP
+	default:
+		return false
+	}
+}
+
+// String is used to implement fmt.Stringer interface.
+func (e ExitInfo) String() string {
+	switch e.Kind {
+	case ExitExited:
+		return fmt.Sprintf("exited with code %v", e.Code)
+	case ExitSignaled:
+		return fmt.Sprintf("terminated by signal %v", e.Signal)
+	case ExitCrashed:
+		if e.Signal == 0 {
+			return fmt.Sprintf("crashed with code %#x", uint32(e.Code))
+		}
+		if e.CoreDumped {
+			return fmt.Sprintf("crashed by signal %v (core dumped)", e.Signal)
+		}
+		return fmt.Sprintf("crashed by signal %v", e.Signal)
+	default:
+		return "unknown exit reason"
+	}
+}
+
+// ExitInfoFromShellCode returns ExitInfo for exit status `code` as reported by POSIX shells in $?, where 128 + N means
+// that the process was terminated by signal N.
+func ExitInfoFromShellCode(code int) ExitInfo {
+	if code > 128 && code < 256 {
+		return ExitInfo{Kind: ExitSignaled, Signal: syscall.Signal(code - 128), Source: ExitSourceShell}
+	}
+	return ExitInfo{Kind: ExitExited, Code: code, Source: ExitSourceShell}
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package terminator
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// SIGCHLD si_code values.
+//
+// See asm-generic/siginfo.h.
+const (
+	cldExited = 1
+	cldKilled = 2
+	cldDumped = 3
+)
+
+// exitEventWait is how long to wait for the exit event of a process which is already gone, as the events are delivered
+// asynchronously.
+const exitEventWait = time.Millisecond * 100
+
+// exitWatcher collects the exit status of a process.
+type exitWatcher struct {
+	pid      int
+	listener *ProcEventListener
+
+	mu         sync.Mutex
+	event      *ProcEvent
+	exited     chan struct{}
+	exitedOnce sync.Once
+}
+
+// watchExit starts collecting the exit status of the process with PID `pid` from the proc connector, if available,
+// until `ctx` is done or the watcher is closed.
+//
+// Pidfd is not used, as it does not expose the exit status of processes which are not children of the current process.
+func watchExit(ctx context.Context, pid int) *exitWatcher {
+	w := &exitWatcher{pid: pid, exited: make(chan struct{})}
+	listener, err := shareProcEvents(ctx)
+	if err != nil {
+		return w
+	}
+	w.listener = listener
+	go func() {
+		for event := range listener.Events() {
+			if event.Kind != ProcEventExit || event.PID != pid {
+				continue
+			}
+			w.mu.Lock()
+			w.event = &event
+			w.mu.Unlock()
+			if event.TID == pid {
+				w.exitedOnce.Do(func() { close(w.exited) })
+			}
+		}
+	}()
+	return w
+}
+
+// info returns ExitInfo of the process, which must be gone.
+//
+// If the process is a child of the current process, its wait status is read without reaping it, so the caller can
+// still wait for it, e.g. with exec.Cmd.Wait.
+func (w *exitWatcher) info() ExitInfo {
+	if status, ok := peekWaitStatus(w.pid); ok {
+		return exitInfoFromWaitStatus(status, ExitSourceWait)
+	}
+	if w.listener == nil {
+		return ExitInfo{Kind: ExitUnknown}
+	}
+	select {
+	case <-w.exited:
+	case <-time.After(exitEventWait):
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.event == nil {
+		return ExitInfo{Kind: ExitUnknown}
+	}
+	return exitInfoFromWaitStatus(syscall.WaitStatus(w.event.ExitCode), ExitSourceProcConnector)
+}
+
+// close stops collecting the exit status.
+func (w *exitWatcher) close() {
+	if w.listener != nil {
+		_ = w.listener.Close()
+	}
+}
+
+// peekWaitStatus returns the wait status of the exited child of the current process with PID `pid` without reaping
+// it. Returns false if the process is not an exited child.
+func peekWaitStatus(pid int) (syscall.WaitStatus, bool) {
+	var info unix.Siginfo
+	err := unix.Waitid(unix.P_PID, pid, &info, unix.WEXITED|unix.WNOHANG|unix.WNOWAIT, nil)
+	if err != nil || info.Signo != int32(unix.SIGCHLD) {
+		return 0, false
+	}
+	// The union of struct siginfo follows si_signo, si_errno and si_code, aligned to the pointer size. Fields of its
+	// SIGCHLD variant are si_pid, si_uid and si_status.
+	align := unsafe.Sizeof(uintptr(0))
+	offset := (unsafe.Sizeof(info.Signo)*3+align-1)&^(align-1) + 8
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	status := syscall.WaitStatus(binary.NativeEndian.Uint32(raw[offset:]))
+	// Convert to the wait(2) status format.
+	switch info.Code {
+	case cldExited:
+		return status << 8, true
+	case cldKilled:
+		return status, true
+	case cldDumped:
+		return status | 0x80, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !windows
+
+package terminator
+
+import "context"
+
+// exitWatcher collects the exit status of a process.
+type exitWatcher struct{}
+
+// watchExit returns a watcher of the process with PID `pid`, which reports unknown exit status as it is not available
+// for processes which are not waited for on this platform.
+func watchExit(ctx context.Context, pid int) *exitWatcher {
+	return &exitWatcher{}
+}
+
+// info returns ExitInfo of the process, which must be gone.
+func (w *exitWatcher) info() ExitInfo {
+	return ExitInfo{Kind: ExitUnknown}
+}
+
+// close stops collecting the exit status.
+func (w *exitWatcher) close() {}
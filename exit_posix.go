@@ -0,0 +1,32 @@
+//go:build !windows
+
+package terminator
+
+import (
+	"os"
+	"syscall"
+)
+
+// ExitInfoFromProcessState returns ExitInfo of a process waited for with os.Process.Wait or exec.Cmd.Wait, described by
+// `state`.
+func ExitInfoFromProcessState(state *os.ProcessState) ExitInfo {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok {
+		return ExitInfo{Kind: ExitExited, Code: state.ExitCode(), Source: ExitSourceProcessState}
+	}
+	return exitInfoFromWaitStatus(status, ExitSourceProcessState)
+}
+
+// exitInfoFromWaitStatus returns ExitInfo for wait status `status` obtained from `source`.
+func exitInfoFromWaitStatus(status syscall.WaitStatus, source ExitSource) ExitInfo {
+	switch {
+	case status.Exited():
+		return ExitInfo{Kind: ExitExited, Code: status.ExitStatus(), Source: source}
+	case status.Signaled() && status.CoreDump():
+		return ExitInfo{Kind: ExitCrashed, Signal: status.Signal(), CoreDumped: true, Source: source}
+	case status.Signaled():
+		return ExitInfo{Kind: ExitSignaled, Signal: status.Signal(), Source: source}
+	default:
+		return ExitInfo{Kind: ExitUnknown, Source: source}
+	}
+}
@@ -0,0 +1,68 @@
+//go:build windows
+
+package terminator
+
+import (
+	"context"
+	"os"
+	"syscall"
+
+	"github.com/SCP002/terminator/internal/wincodes"
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code of a process which is still running.
+const stillActive = 259
+
+// exitWatcher collects the exit status of a process.
+type exitWatcher struct {
+	handle windows.Handle
+}
+
+// watchExit opens the process with PID `pid`, so its exit code stays available after it exits.
+func watchExit(ctx context.Context, pid int) *exitWatcher {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return &exitWatcher{}
+	}
+	return &exitWatcher{handle: handle}
+}
+
+// info returns ExitInfo of the process, which must be gone.
+func (w *exitWatcher) info() ExitInfo {
+	if w.handle == 0 {
+		return ExitInfo{Kind: ExitUnknown}
+	}
+	var code uint32
+	if err := windows.GetExitCodeProcess(w.handle, &code); err != nil || code == stillActive {
+		return ExitInfo{Kind: ExitUnknown}
+	}
+	return exitInfoFromCode(code, ExitSourceExitCode)
+}
+
+// close releases the process handle.
+func (w *exitWatcher) close() {
+	if w.handle != 0 {
+		_ = windows.CloseHandle(w.handle)
+	}
+}
+
+// ExitInfoFromProcessState returns ExitInfo of a process waited for with os.Process.Wait or exec.Cmd.Wait, described by
+// `state`.
+func ExitInfoFromProcessState(state *os.ProcessState) ExitInfo {
+	return exitInfoFromCode(uint32(state.ExitCode()), ExitSourceProcessState)
+}
+
+// exitInfoFromCode returns ExitInfo for exit code `code` obtained from `source`.
+func exitInfoFromCode(code uint32, source ExitSource) ExitInfo {
+	switch {
+	case int(code) == wincodes.STATUS_CONTROL_C_EXIT:
+		// Default handler of Ctrl + C or Ctrl + Break.
+		return ExitInfo{Kind: ExitSignaled, Code: int(code), Signal: syscall.SIGINT, Source: source}
+	case code&0xC0000000 == 0xC0000000:
+		// NTSTATUS with error severity, e.g. STATUS_ACCESS_VIOLATION.
+		return ExitInfo{Kind: ExitCrashed, Code: int(code), Source: source}
+	default:
+		return ExitInfo{Kind: ExitExited, Code: int(code), Source: source}
+	}
+}
@@ -0,0 +1,207 @@
+package terminator
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+)
+
+// FailureMode is a reaction of StopGraph to a node which failed to stop.
+type FailureMode int
+
+const (
+	// FailContinue keeps stopping the other nodes, including the ones ordered after the failed node.
+	FailContinue FailureMode = iota
+	// FailAbort stops scheduling new nodes, makes the nodes being stopped skip to their kill stage and kills processes
+	// of the nodes which were not started yet, respecting the concurrency limit.
+	FailAbort
+)
+
+// GraphNode is a group of processes stopped as a single step of StopGraph.
+type GraphNode struct {
+	// Name is a unique name of the node, used in edges.
+	Name string
+	// Selector resolves the processes of the node, e.g. PIDSelector or PidfileSelector (stopped as with
+	// StopPidfileWithContext).
+	Selector Selector
+	Policy   Policy
+}
+
+// GraphEdge orders stop of two nodes: the node Before is stopped before the node After is started to be stopped.
+type GraphEdge struct {
+	Before string
+	After  string
+}
+
+// StopGraph is a dependency graph of nodes to stop, e.g. "stop workers and API before the database".
+type StopGraph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+	// Concurrency limits how many nodes are stopped at once. Unlimited if 0.
+	Concurrency int
+	// OnFailure defines what to do if a node fails to stop.
+	OnFailure FailureMode
+}
+
+// GraphNodeResult is an outcome of a stop of a single node of StopGraph.
+type GraphNodeResult struct {
+	Name    string
+	Results []StopResult
+	Err     error
+	// Forced is set to true if the node was killed because of FailAbort rather than stopped with it's policy: either it
+	// was being stopped when another node failed and skipped to it's kill stage, or it was not started yet.
+	Forced bool
+}
+
+// forcePolicy is used to kill the remaining nodes on FailAbort.
+var forcePolicy = Policy{Stages: []Stage{{Action: ActionKill, Grace: time.Second * 5}}}
+
+// Stop is the same as StopWithContext with background context.
+func (g StopGraph) Stop() ([]GraphNodeResult, error) {
+	return g.StopWithContext(context.Background())
+}
+
+// StopWithContext stops all nodes of the graph using context `ctx`, respecting the order defined by edges and
+// concurrency limit. Nodes which are not ordered relative to each other are stopped in parallel.
+//
+// Returns results of all nodes in completion order and combined errors of failed nodes.
+func (g StopGraph) StopWithContext(ctx context.Context) ([]GraphNodeResult, error) {
+	nodes, after, pending, err := g.validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "Stop graph")
+	}
+
+	concurrency := g.Concurrency
+	if concurrency <= 0 {
+		concurrency = len(g.Nodes)
+	}
+	ready := []string{}
+	for _, node := range g.Nodes {
+		if pending[node.Name] == 0 {
+			ready = append(ready, node.Name)
+		}
+	}
+
+	// Closed on FailAbort to make the nodes being stopped skip to their kill stage.
+	nodeCtx, force := withForce(ctx)
+	defer force()
+
+	results := []GraphNodeResult{}
+	done := make(chan GraphNodeResult)
+	started := map[string]bool{}
+	running := map[string]bool{}
+	forced := map[string]bool{}
+	aborted := false
+	var result error
+	for {
+		for len(running) < concurrency && len(ready) > 0 && ctx.Err() == nil {
+			name := ready[0]
+			ready = ready[1:]
+			started[name] = true
+			running[name] = true
+			node := nodes[name]
+			if aborted {
+				node.Policy = forcePolicy
+				forced[name] = true
+			}
+			go func(node GraphNode) {
+				stopResults, err := StopSelectorWithContext(nodeCtx, node.Selector, node.Policy)
+				done <- GraphNodeResult{Name: node.Name, Results: stopResults, Err: err}
+			}(node)
+		}
+		if len(running) == 0 {
+			break
+		}
+
+		nodeResult := <-done
+		delete(running, nodeResult.Name)
+		nodeResult.Forced = forced[nodeResult.Name]
+		results = append(results, nodeResult)
+		if nodeResult.Err != nil {
+			format := lo.Ternary(nodeResult.Forced, "Kill node %v", "Stop node %v")
+			result = errors.CombineErrors(result, errors.Wrapf(nodeResult.Err, format, nodeResult.Name))
+			if g.OnFailure == FailAbort && !aborted {
+				// Kill the nodes being stopped and the nodes which were not started, ignoring the order as the graph
+				// failed anyway.
+				aborted = true
+				force()
+				for name := range running {
+					forced[name] = true
+				}
+				ready = lo.FilterMap(g.Nodes, func(node GraphNode, _ int) (string, bool) {
+					return node.Name, !started[node.Name]
+				})
+				continue
+			}
+		}
+		if aborted {
+			continue
+		}
+		for _, next := range after[nodeResult.Name] {
+			pending[next]--
+			if pending[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return results, errors.CombineErrors(result, errors.Wrap(ctx.Err(), "Stop graph"))
+	}
+	return results, result
+}
+
+// validate checks that node names are unique, edges refer to existing nodes and there are no cycles.
+//
+// Returns nodes by name, names of the nodes ordered directly after each node and number of nodes each node waits for.
+func (g StopGraph) validate() (map[string]GraphNode, map[string][]string, map[string]int, error) {
+	nodes := map[string]GraphNode{}
+	for _, node := range g.Nodes {
+		if _, ok := nodes[node.Name]; ok {
+			return nil, nil, nil, errors.Newf("Duplicate node name %q", node.Name)
+		}
+		if node.Selector == nil {
+			return nil, nil, nil, errors.Newf("Node %q has no selector", node.Name)
+		}
+		nodes[node.Name] = node
+	}
+	after := map[string][]string{}
+	pending := map[string]int{}
+	for _, edge := range g.Edges {
+		for _, name := range []string{edge.Before, edge.After} {
+			if _, ok := nodes[name]; !ok {
+				return nil, nil, nil, errors.Newf("Edge refers to unknown node %q", name)
+			}
+		}
+		after[edge.Before] = append(after[edge.Before], edge.After)
+		pending[edge.After]++
+	}
+
+	// Kahn's algorithm: all nodes must be reachable in topological order.
+	left := map[string]int{}
+	queue := []string{}
+	for name := range nodes {
+		left[name] = pending[name]
+		if left[name] == 0 {
+			queue = append(queue, name)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, next := range after[name] {
+			left[next]--
+			if left[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	if visited != len(nodes) {
+		return nil, nil, nil, errors.New("Edges form a cycle")
+	}
+	return nodes, after, pending, nil
+}
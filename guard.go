@@ -0,0 +1,168 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// GuardOptions configures the safety guards checked before processes are signaled, killed, suspended or stopped, and
+// before messages are written to their input or their windows are closed.
+//
+// The guards always protect the init process (PID 1), the current process and it's ancestors, and kernel threads.
+type GuardOptions struct {
+	// ProtectedNames is a list of process names (without extension) which are never acted upon, case-insensitive.
+	ProtectedNames []string
+	// MaxTargets is a maximum number of processes a single operation can act upon. Unlimited if 0.
+	MaxTargets int
+	// BulkThreshold is a number of processes above which an operation requires confirmation. Confirmation is never
+	// required if 0.
+	BulkThreshold int
+	// Confirm is called with the name of the operation and PID's of it's targets if the number of targets is above
+	// BulkThreshold. The operation is refused if it returns false or is nil.
+	Confirm func(op string, pids []int) bool
+}
+
+// DefaultGuardOptions returns guard options protecting well-known critical system processes, limiting an operation to
+// 100 processes and requiring confirmation for operations on more than 10 processes.
+func DefaultGuardOptions() GuardOptions {
+	return GuardOptions{
+		ProtectedNames: slices.Clone(defaultProtectedNames),
+		MaxTargets:     100,
+		BulkThreshold:  10,
+	}
+}
+
+var (
+	guardOptionsMu sync.RWMutex
+	guardOptions   = DefaultGuardOptions()
+)
+
+// SetGuardOptions replaces the guard options used by all operations with `opts`.
+func SetGuardOptions(opts GuardOptions) {
+	guardOptionsMu.Lock()
+	defer guardOptionsMu.Unlock()
+	guardOptions = opts
+}
+
+// GetGuardOptions returns the guard options used by all operations.
+func GetGuardOptions() GuardOptions {
+	guardOptionsMu.RLock()
+	defer guardOptionsMu.RUnlock()
+	return guardOptions
+}
+
+// guardKey is a context key to skip the guards.
+type guardKey struct{}
+
+// WithGuardOverride returns a copy of `ctx` with which the operations skip the safety guards.
+//
+// Use with care, e.g. after asking the user for explicit confirmation.
+func WithGuardOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, guardKey{}, true)
+}
+
+// ErrGuardViolation indicates that an operation was refused by the safety guards. PID is 0 if the violation is not
+// related to a particular process, e.g. too many targets.
+type ErrGuardViolation struct {
+	Op     string
+	PID    int
+	Reason string
+}
+
+// Error is used to implement error interface.
+func (e ErrGuardViolation) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("Operation %v refused by the safety guards: %v", e.Op, e.Reason)
+	}
+	return fmt.Sprintf("Operation %v refused by the safety guards: process with PID %v is %v", e.Op, e.PID, e.Reason)
+}
+
+// newErrGuardViolation returns new ErrGuardViolation with operation `op`, PID `pid` and reason `reason`.
+func newErrGuardViolation(op string, pid int, reason string) ErrGuardViolation {
+	return ErrGuardViolation{Op: op, PID: pid, Reason: reason}
+}
+
+// checkGuard returns ErrGuardViolation if the operation `op` on processes `pids` is not allowed by the guard options,
+// and a copy of `ctx` with which the nested operations skip the guards.
+//
+// Does nothing if `ctx` was returned by WithGuardOverride or a previous checkGuard call.
+func checkGuard(ctx context.Context, op string, pids []int) (context.Context, error) {
+	if skip, _ := ctx.Value(guardKey{}).(bool); skip {
+		return ctx, nil
+	}
+	opts := GetGuardOptions()
+	if opts.MaxTargets > 0 && len(pids) > opts.MaxTargets {
+		reason := fmt.Sprintf("%v targets exceed the limit of %v", len(pids), opts.MaxTargets)
+		return ctx, newErrGuardViolation(op, 0, reason)
+	}
+
+	ancestors := selfAncestors()
+	for _, pid := range pids {
+		if reason, ok := protectedReason(pid, ancestors, opts.ProtectedNames); ok {
+			return ctx, newErrGuardViolation(op, pid, reason)
+		}
+	}
+
+	if opts.BulkThreshold > 0 && len(pids) > opts.BulkThreshold {
+		if opts.Confirm == nil || !opts.Confirm(op, slices.Clone(pids)) {
+			reason := fmt.Sprintf("operation on %v processes was not confirmed", len(pids))
+			return ctx, newErrGuardViolation(op, 0, reason)
+		}
+	}
+	return context.WithValue(ctx, guardKey{}, true), nil
+}
+
+// protectedReason returns description of why the process with PID `pid` is protected and true, or false if it is not.
+//
+// `ancestors` is a set of PID's of the ancestors of the current process, `names` is a list of protected names.
+func protectedReason(pid int, ancestors map[int]bool, names []string) (string, bool) {
+	switch {
+	case pid == 1:
+		return "the init process", true
+	case pid == os.Getpid():
+		return "the current process", true
+	case ancestors[pid]:
+		return "an ancestor of the current process", true
+	case isKernelThread(pid):
+		return "a kernel thread", true
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return "", false
+	}
+	name, err := proc.Name()
+	if err != nil {
+		return "", false
+	}
+	if slices.ContainsFunc(names, func(protected string) bool { return exeName(protected) == exeName(name) }) {
+		return fmt.Sprintf("protected %v", name), true
+	}
+	return "", false
+}
+
+// selfAncestors returns a set of PID's of the ancestors of the current process.
+func selfAncestors() map[int]bool {
+	ancestors := map[int]bool{}
+	pid := os.Getppid()
+	for pid > 0 && !ancestors[pid] {
+		ancestors[pid] = true
+		proc, err := process.NewProcess(int32(pid))
+		if err != nil {
+			break
+		}
+		ppid, err := proc.Ppid()
+		if err != nil || int(ppid) == pid {
+			break
+		}
+		pid = int(ppid)
+	}
+	return ancestors
+}
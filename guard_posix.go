@@ -0,0 +1,8 @@
+//go:build !windows
+
+package terminator
+
+// defaultProtectedNames is a list of critical system processes protected by DefaultGuardOptions.
+var defaultProtectedNames = []string{
+	"init", "systemd", "launchd", "kthreadd", "sshd", "dbus-daemon", "loginwindow", "WindowServer",
+}
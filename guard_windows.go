@@ -0,0 +1,8 @@
+//go:build windows
+
+package terminator
+
+// defaultProtectedNames is a list of critical system processes protected by DefaultGuardOptions.
+var defaultProtectedNames = []string{
+	"System", "smss", "csrss", "wininit", "winlogon", "services", "lsass", "svchost", "explorer", "dwm",
+}
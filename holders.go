@@ -0,0 +1,116 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+)
+
+// HoldKind is a way a process holds a file.
+type HoldKind string
+
+const (
+	// HoldFD indicates an open file descriptor.
+	HoldFD HoldKind = "fd"
+	// HoldCwd indicates the current working directory.
+	HoldCwd HoldKind = "cwd"
+	// HoldRoot indicates the root directory (e.g. changed with chroot).
+	HoldRoot HoldKind = "root"
+	// HoldExe indicates the executable.
+	HoldExe HoldKind = "exe"
+	// HoldMmap indicates a memory mapped file, such as a shared library.
+	HoldMmap HoldKind = "mmap"
+)
+
+// FileHolder is a process holding a file.
+type FileHolder struct {
+	PID  int
+	Kind HoldKind
+	// Path is a path of the held file.
+	Path string
+	// FD is a file descriptor number for HoldFD.
+	FD int
+}
+
+// String is used to implement fmt.Stringer interface.
+func (h FileHolder) String() string {
+	if h.Kind == HoldFD {
+		return fmt.Sprintf("PID %v holds %v (fd %v)", h.PID, h.Path, h.FD)
+	}
+	return fmt.Sprintf("PID %v holds %v (%v)", h.PID, h.Path, h.Kind)
+}
+
+// PathSelector selects processes holding files under Path, see ByPath.
+type PathSelector struct {
+	Path string
+}
+
+// Select is used to implement Selector interface.
+func (s PathSelector) Select(ctx context.Context) ([]int, error) {
+	holders, err := ByPathWithContext(ctx, s.Path)
+	if err != nil {
+		return nil, err
+	}
+	return holderPids(holders), nil
+}
+
+// String is used to implement Selector interface.
+func (s PathSelector) String() string {
+	return fmt.Sprintf("path %v", s.Path)
+}
+
+// ByPath is the same as ByPathWithContext with background context.
+func ByPath(path string) ([]FileHolder, error) {
+	return ByPathWithContext(context.Background(), path)
+}
+
+// PathStopResult is an outcome of a graceful stop of a process holding files under a path.
+type PathStopResult struct {
+	StopResult
+	// Holders describes which files the process held.
+	Holders []FileHolder
+}
+
+// StopPath is the same as StopPathWithContext with background context.
+func StopPath(path string, policy Policy) ([]PathStopResult, error) {
+	return StopPathWithContext(context.Background(), path, policy)
+}
+
+// StopPathWithContext stops all processes holding files under `path` (see ByPath) with `policy` using context `ctx`,
+// concurrently.
+//
+// Returns results of every process with the files it held, and combined errors of failed stops.
+func StopPathWithContext(ctx context.Context, path string, policy Policy) ([]PathStopResult, error) {
+	holders, err := ByPathWithContext(ctx, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Stop processes holding %v", path)
+	}
+	pids := holderPids(holders)
+	emit(ctx, ObserverEvent{Kind: EventTargetResolved, PIDs: pids, Target: PathSelector{Path: path}.String()})
+	stopResults, err := stopPids(ctx, pids, policy)
+	if stopResults == nil {
+		return nil, errors.Wrapf(err, "Stop processes holding %v", path)
+	}
+	results := make([]PathStopResult, len(stopResults))
+	for i, stopResult := range stopResults {
+		results[i] = PathStopResult{
+			StopResult: stopResult,
+			Holders: lo.Filter(holders, func(holder FileHolder, _ int) bool {
+				return holder.PID == stopResult.PID
+			}),
+		}
+	}
+	return results, errors.Wrapf(err, "Stop processes holding %v", path)
+}
+
+// holderPids returns unique PID's of `holders` in ascending order.
+func holderPids(holders []FileHolder) []int {
+	pids := lo.Map(holders, func(holder FileHolder, _ int) int {
+		return holder.PID
+	})
+	slices.Sort(pids)
+	return slices.Compact(pids)
+}
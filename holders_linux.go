@@ -0,0 +1,163 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// ByPathWithContext returns processes holding files under `path` using context `ctx`: as open file descriptors,
+// current working or root directories, executables or memory mapped files. `path` itself is matched as well.
+//
+// If `path` is a mount point, files on the mounted file system are matched even if they are reached through another
+// path (e.g. a bind mount).
+//
+// The current process is never returned. Files of processes of other users are not visible without root privilegies.
+func ByPathWithContext(ctx context.Context, path string) ([]FileHolder, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Find processes holding %v", path)
+	}
+	// Targets of links in the proc filesystem have symbolic links resolved.
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return nil, errors.Wrapf(err, "Find processes holding %v", path)
+	}
+	matcher, err := newPathMatcher(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Find processes holding %v", path)
+	}
+	entries, err := os.ReadDir(procPath())
+	if err != nil {
+		return nil, errors.Wrapf(err, "Find processes holding %v", path)
+	}
+
+	self := os.Getpid()
+	holders := []FileHolder{}
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "Find processes holding %v", path)
+		default:
+		}
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		holders = append(holders, procFileHolders(pid, matcher)...)
+	}
+	return holders, nil
+}
+
+// pathMatcher matches files under a path.
+type pathMatcher struct {
+	path string
+	// dev is a device of the file system mounted at the path, or nil if the path is not a mount point.
+	dev *uint64
+}
+
+// newPathMatcher returns new pathMatcher for absolute path `path`.
+func newPathMatcher(path string) (pathMatcher, error) {
+	matcher := pathMatcher{path: filepath.Clean(path)}
+	var stat, parentStat unix.Stat_t
+	if err := unix.Stat(matcher.path, &stat); err != nil {
+		return matcher, errors.Wrapf(err, "Stat %v", path)
+	}
+	if err := unix.Stat(filepath.Dir(matcher.path), &parentStat); err != nil {
+		return matcher, errors.Wrapf(err, "Stat %v", filepath.Dir(path))
+	}
+	if stat.Dev != parentStat.Dev && stat.Mode&unix.S_IFMT == unix.S_IFDIR {
+		dev := stat.Dev
+		matcher.dev = &dev
+	}
+	return matcher, nil
+}
+
+// matchPath returns true if `target` is the path or is under it.
+func (m pathMatcher) matchPath(target string) bool {
+	target = strings.TrimSuffix(target, " (deleted)")
+	return target == m.path || m.path == "/" || strings.HasPrefix(target, m.path+"/")
+}
+
+// matchLink returns true if the file resolved from the proc filesystem link `link` with target `target` is the path,
+// is under it or is on the file system mounted at the path.
+func (m pathMatcher) matchLink(link string, target string) bool {
+	if m.matchPath(target) {
+		return true
+	}
+	if m.dev == nil {
+		return false
+	}
+	var stat unix.Stat_t
+	return unix.Stat(link, &stat) == nil && stat.Dev == *m.dev
+}
+
+// matchDev returns true if the device "major:minor" in /proc/<pid>/maps format `dev` is the device of the file system
+// mounted at the path.
+func (m pathMatcher) matchDev(dev string) bool {
+	if m.dev == nil {
+		return false
+	}
+	major, minor, ok := strings.Cut(dev, ":")
+	if !ok {
+		return false
+	}
+	majorNum, err1 := strconv.ParseUint(major, 16, 32)
+	minorNum, err2 := strconv.ParseUint(minor, 16, 32)
+	return err1 == nil && err2 == nil && unix.Mkdev(uint32(majorNum), uint32(minorNum)) == *m.dev
+}
+
+// procFileHolders returns the ways the process with PID `pid` holds files matched by `matcher`.
+func procFileHolders(pid int, matcher pathMatcher) []FileHolder {
+	dir := strconv.Itoa(pid)
+	holders := []FileHolder{}
+
+	for _, kind := range []HoldKind{HoldCwd, HoldRoot, HoldExe} {
+		link := procPath(dir, string(kind))
+		target, err := os.Readlink(link)
+		if err == nil && matcher.matchLink(link, target) {
+			holders = append(holders, FileHolder{PID: pid, Kind: kind, Path: target})
+		}
+	}
+
+	fds, _ := os.ReadDir(procPath(dir, "fd"))
+	for _, fd := range fds {
+		link := procPath(dir, "fd", fd.Name())
+		target, err := os.Readlink(link)
+		// Sockets, pipes and other anonymous files are not on the file system.
+		if err != nil || !strings.HasPrefix(target, "/") || !matcher.matchLink(link, target) {
+			continue
+		}
+		num, _ := strconv.Atoi(fd.Name())
+		holders = append(holders, FileHolder{PID: pid, Kind: HoldFD, Path: target, FD: num})
+	}
+
+	maps, err := os.Open(procPath(dir, "maps"))
+	if err != nil {
+		return holders
+	}
+	defer maps.Close()
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(maps)
+	for scanner.Scan() {
+		// address perms offset dev inode pathname.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || !strings.HasPrefix(fields[5], "/") {
+			continue
+		}
+		target := strings.Join(fields[5:], " ")
+		if seen[target] || (!matcher.matchPath(target) && !matcher.matchDev(fields[3])) {
+			continue
+		}
+		seen[target] = true
+		holders = append(holders, FileHolder{PID: pid, Kind: HoldMmap, Path: target})
+	}
+	return holders
+}
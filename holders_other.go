@@ -0,0 +1,14 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ByPathWithContext is only implemented on Linux, returns ErrNotSupported.
+func ByPathWithContext(ctx context.Context, path string) ([]FileHolder, error) {
+	return nil, errors.Wrapf(ErrNotSupported, "Find processes holding %v", path)
+}
@@ -0,0 +1,150 @@
+package terminator
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultDurationBuckets are upper bounds (in seconds) of the stop duration histogram buckets of MetricsObserver.
+var DefaultDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Histogram is a snapshot of a histogram in Prometheus style.
+type Histogram struct {
+	// Buckets are upper bounds of the buckets.
+	Buckets []float64
+	// Counts are cumulative numbers of observations less or equal to the upper bound of each bucket.
+	Counts []uint64
+	Sum    float64
+	Count  uint64
+}
+
+// observe adds `value` to the histogram.
+func (h *Histogram) observe(value float64) {
+	for i, bound := range h.Buckets {
+		if value <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Sum += value
+	h.Count++
+}
+
+// Metrics is a snapshot of metrics collected by MetricsObserver.
+type Metrics struct {
+	// Stopped is a number of processes stopped.
+	Stopped uint64
+	// Failed is a number of processes which failed to stop.
+	Failed uint64
+	// Escalations are numbers of escalations by the stage escalated to.
+	Escalations map[int]uint64
+	// StageTimeouts are numbers of grace period timeouts by stage.
+	StageTimeouts map[int]uint64
+	// SignalsSent are numbers of signals sent by stop stages by signal name.
+	SignalsSent map[string]uint64
+	// StopDuration is a histogram of stop durations in seconds.
+	StopDuration Histogram
+}
+
+// MetricsObserver collects counters and histograms of stops in memory, to be exposed e.g. in Prometheus text format
+// with WritePrometheus.
+type MetricsObserver struct {
+	mu      sync.Mutex
+	metrics Metrics
+}
+
+// NewMetricsObserver returns new MetricsObserver with stop duration histogram buckets `buckets` (in seconds), or
+// DefaultDurationBuckets if empty.
+func NewMetricsObserver(buckets ...float64) *MetricsObserver {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	buckets = slices.Sorted(slices.Values(buckets))
+	return &MetricsObserver{metrics: Metrics{
+		Escalations:   map[int]uint64{},
+		StageTimeouts: map[int]uint64{},
+		SignalsSent:   map[string]uint64{},
+		StopDuration:  Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))},
+	}}
+}
+
+// Observe is used to implement Observer interface.
+func (o *MetricsObserver) Observe(event ObserverEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch event.Kind {
+	case EventProcessExited:
+		o.metrics.Stopped++
+		o.metrics.StopDuration.observe(event.Elapsed.Seconds())
+	case EventStopFailed:
+		o.metrics.Failed++
+		o.metrics.StopDuration.observe(event.Elapsed.Seconds())
+	case EventEscalated:
+		o.metrics.Escalations[event.Stage]++
+	case EventStageTimedOut:
+		o.metrics.StageTimeouts[event.Stage]++
+	case EventSignalSent:
+		o.metrics.SignalsSent[event.Signal.String()]++
+	}
+}
+
+// Metrics returns a snapshot of the collected metrics.
+func (o *MetricsObserver) Metrics() Metrics {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	metrics := o.metrics
+	metrics.Escalations = maps.Clone(o.metrics.Escalations)
+	metrics.StageTimeouts = maps.Clone(o.metrics.StageTimeouts)
+	metrics.SignalsSent = maps.Clone(o.metrics.SignalsSent)
+	metrics.StopDuration.Buckets = slices.Clone(o.metrics.StopDuration.Buckets)
+	metrics.StopDuration.Counts = slices.Clone(o.metrics.StopDuration.Counts)
+	return metrics
+}
+
+// WritePrometheus writes the collected metrics to `w` in Prometheus text exposition format.
+func (o *MetricsObserver) WritePrometheus(w io.Writer) error {
+	m := o.Metrics()
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("# HELP terminator_stops_total Number of processes stopped or failed to stop.\n")
+	printf("# TYPE terminator_stops_total counter\n")
+	printf("terminator_stops_total{result=\"stopped\"} %v\n", m.Stopped)
+	printf("terminator_stops_total{result=\"failed\"} %v\n", m.Failed)
+
+	printf("# HELP terminator_escalations_total Number of escalations by the stage escalated to.\n")
+	printf("# TYPE terminator_escalations_total counter\n")
+	for _, stage := range slices.Sorted(maps.Keys(m.Escalations)) {
+		printf("terminator_escalations_total{stage=\"%v\"} %v\n", stage, m.Escalations[stage])
+	}
+
+	printf("# HELP terminator_stage_timeouts_total Number of stage grace period timeouts.\n")
+	printf("# TYPE terminator_stage_timeouts_total counter\n")
+	for _, stage := range slices.Sorted(maps.Keys(m.StageTimeouts)) {
+		printf("terminator_stage_timeouts_total{stage=\"%v\"} %v\n", stage, m.StageTimeouts[stage])
+	}
+
+	printf("# HELP terminator_signals_sent_total Number of signals sent by stop stages.\n")
+	printf("# TYPE terminator_signals_sent_total counter\n")
+	for _, sig := range slices.Sorted(maps.Keys(m.SignalsSent)) {
+		printf("terminator_signals_sent_total{signal=%q} %v\n", sig, m.SignalsSent[sig])
+	}
+
+	printf("# HELP terminator_stop_duration_seconds Duration of process stops.\n")
+	printf("# TYPE terminator_stop_duration_seconds histogram\n")
+	for i, bound := range m.StopDuration.Buckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		printf("terminator_stop_duration_seconds_bucket{le=\"%v\"} %v\n", le, m.StopDuration.Counts[i])
+	}
+	printf("terminator_stop_duration_seconds_bucket{le=\"+Inf\"} %v\n", m.StopDuration.Count)
+	printf("terminator_stop_duration_seconds_sum %v\n", m.StopDuration.Sum)
+	printf("terminator_stop_duration_seconds_count %v\n", m.StopDuration.Count)
+	return err
+}
@@ -0,0 +1,189 @@
+package terminator
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ObserverEventKind is a kind of event reported to observers.
+type ObserverEventKind string
+
+const (
+	// EventTargetResolved indicates that the processes to stop were resolved. ObserverEvent.PIDs is set.
+	EventTargetResolved ObserverEventKind = "target_resolved"
+	// EventStageStarted indicates that a stop stage started.
+	EventStageStarted ObserverEventKind = "stage_started"
+	// EventSignalSent indicates that a stop stage sent a signal. ObserverEvent.Signal is set.
+	EventSignalSent ObserverEventKind = "signal_sent"
+	// EventStageTimedOut indicates that the process is still running after the grace period of a stop stage.
+	EventStageTimedOut ObserverEventKind = "stage_timed_out"
+	// EventEscalated indicates that the stop moves to the next, more forceful stage.
+	EventEscalated ObserverEventKind = "escalated"
+	// EventProcessExited indicates that the process exited. ObserverEvent.Stage is the stage which stopped it, or 0 if
+	// it was not running when the stop started.
+	EventProcessExited ObserverEventKind = "process_exited"
+	// EventStopFailed indicates that the stop failed. ObserverEvent.Err is set.
+	EventStopFailed ObserverEventKind = "stop_failed"
+)
+
+// ObserverEvent is a notification about the progress of an operation.
+type ObserverEvent struct {
+	Kind ObserverEventKind
+	Time time.Time
+	// PID is PID of the process the event is related to. 0 for EventTargetResolved.
+	PID int
+	// PIDs are PID's of the resolved processes for EventTargetResolved.
+	PIDs []int
+	// Target is a description of what was resolved for EventTargetResolved, e.g. selector.
+	Target string
+	// Stage is a number of the stop stage (starting from 1) the event is related to.
+	Stage int
+	// Action is an action of the stop stage.
+	Action StageAction
+	// Signal is the signal sent for EventSignalSent.
+	Signal syscall.Signal
+	// Elapsed is the time since the stop of the process started.
+	Elapsed time.Duration
+	// Err is the error for EventStopFailed.
+	Err error
+}
+
+// Observer receives events about the progress of operations. Implementations must be safe for concurrent use and
+// should return quickly, as they are called synchronously.
+type Observer interface {
+	Observe(event ObserverEvent)
+}
+
+// ObserverFunc is a function implementing Observer interface.
+type ObserverFunc func(event ObserverEvent)
+
+// Observe is used to implement Observer interface.
+func (f ObserverFunc) Observe(event ObserverEvent) {
+	f(event)
+}
+
+var (
+	observersMu sync.RWMutex
+	// observers are the registered observers, wrapped to be removed by identity as observers may be uncomparable (e.g.
+	// ObserverFunc).
+	observers []*registeredObserver
+)
+
+// registeredObserver is an observer registered with AddObserver.
+type registeredObserver struct {
+	Observer
+}
+
+// AddObserver registers `observer` to receive events of all operations. Returns function to unregister it.
+func AddObserver(observer Observer) func() {
+	registered := &registeredObserver{observer}
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	observers = append(observers, registered)
+	return func() {
+		observersMu.Lock()
+		defer observersMu.Unlock()
+		observers = slices.DeleteFunc(slices.Clone(observers), func(o *registeredObserver) bool { return o == registered })
+	}
+}
+
+// observerKey is a context key of the observers of an operation.
+type observerKey struct{}
+
+// WithObserver returns a copy of `ctx` with which the operations report events to `observer` in addition to the
+// observers registered with AddObserver.
+func WithObserver(ctx context.Context, observer Observer) context.Context {
+	parent, _ := ctx.Value(observerKey{}).([]Observer)
+	return context.WithValue(ctx, observerKey{}, append(slices.Clip(parent), observer))
+}
+
+// emit reports `event` to the observers of `ctx` and the registered observers.
+func emit(ctx context.Context, event ObserverEvent) {
+	observersMu.RLock()
+	global := observers
+	observersMu.RUnlock()
+	scoped, _ := ctx.Value(observerKey{}).([]Observer)
+	if len(global) == 0 && len(scoped) == 0 {
+		return
+	}
+	event.Time = time.Now()
+	for _, observer := range global {
+		observer.Observe(event)
+	}
+	for _, observer := range scoped {
+		observer.Observe(event)
+	}
+}
+
+// SlogObserver writes events to a structured logger.
+type SlogObserver struct {
+	logger *slog.Logger
+}
+
+// NewSlogObserver returns new SlogObserver writing to `logger` with debug level, or warning level for timeouts,
+// escalations and failures.
+func NewSlogObserver(logger *slog.Logger) *SlogObserver {
+	return &SlogObserver{logger: logger}
+}
+
+// Observe is used to implement Observer interface.
+func (o *SlogObserver) Observe(event ObserverEvent) {
+	attrs := []slog.Attr{slog.String("event", string(event.Kind))}
+	if event.PID != 0 {
+		attrs = append(attrs, slog.Int("pid", event.PID))
+	}
+	if event.Kind == EventTargetResolved {
+		attrs = append(attrs, slog.String("target", event.Target), slog.Any("pids", event.PIDs))
+	}
+	if event.Stage != 0 {
+		attrs = append(attrs, slog.Int("stage", event.Stage), slog.String("action", string(event.Action)))
+	}
+	if event.Signal != 0 {
+		attrs = append(attrs, slog.String("signal", event.Signal.String()))
+	}
+	if event.Elapsed != 0 {
+		attrs = append(attrs, slog.Duration("elapsed", event.Elapsed))
+	}
+	level := slog.LevelDebug
+	switch event.Kind {
+	case EventStageTimedOut, EventEscalated:
+		level = slog.LevelWarn
+	case EventStopFailed:
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+	o.logger.LogAttrs(context.Background(), level, "terminator event", attrs...)
+}
+
+// LogObserver writes events to a standard logger.
+type LogObserver struct {
+	logger *log.Logger
+}
+
+// NewLogObserver returns new LogObserver writing to `logger`, or to the standard logger if nil.
+func NewLogObserver(logger *log.Logger) *LogObserver {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogObserver{logger: logger}
+}
+
+// Observe is used to implement Observer interface.
+func (o *LogObserver) Observe(event ObserverEvent) {
+	switch event.Kind {
+	case EventTargetResolved:
+		o.logger.Printf("%v: %v -> %v", event.Kind, event.Target, event.PIDs)
+	case EventSignalSent:
+		o.logger.Printf("%v: pid %v, stage %v, signal %v", event.Kind, event.PID, event.Stage, event.Signal)
+	case EventStopFailed:
+		o.logger.Printf("%v: pid %v after %v: %v", event.Kind, event.PID, event.Elapsed, event.Err)
+	default:
+		o.logger.Printf("%v: pid %v, stage %v (%v) after %v", event.Kind, event.PID, event.Stage, event.Action,
+			event.Elapsed)
+	}
+}
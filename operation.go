@@ -0,0 +1,296 @@
+package terminator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// forceKillGrace is how long to wait for the process to exit after it was killed because of Operation.Cancel, if the
+// policy has no kill stage.
+const forceKillGrace = time.Second * 5
+
+// Operation is a stop running in background, see StartStop.
+type Operation struct {
+	force     chan struct{}
+	forceOnce sync.Once
+	done      chan struct{}
+	results   []StopResult
+	err       error
+
+	mu         sync.Mutex
+	queue      []ObserverEvent
+	queued     chan struct{}
+	events     chan ObserverEvent
+	eventsOnce sync.Once
+	// stopped is closed by StopEvents.
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// StartStop starts StopWithContext with `ctx`, `pid` and `policy` in background.
+func StartStop(ctx context.Context, pid int, policy Policy) *Operation {
+	return startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		result, err := StopWithContext(ctx, pid, policy)
+		return []StopResult{result}, err
+	})
+}
+
+// StartStopSelector starts StopSelectorWithContext with `ctx`, `sel` and `policy` in background.
+func StartStopSelector(ctx context.Context, sel Selector, policy Policy) *Operation {
+	return startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		return StopSelectorWithContext(ctx, sel, policy)
+	})
+}
+
+// StartExecutePlan starts ExecutePlanWithContext with `ctx` and `plan` in background.
+func StartExecutePlan(ctx context.Context, plan Plan) *Operation {
+	return startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		return ExecutePlanWithContext(ctx, plan)
+	})
+}
+
+// StartStopPort starts StopPortWithContext with `ctx`, `proto`, `port` and `policy` in background.
+func StartStopPort(ctx context.Context, proto string, port int, policy Policy) *Operation {
+	return startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		return StopPortWithContext(ctx, proto, port, policy)
+	})
+}
+
+// StartStopPidfile starts StopPidfileWithContext with `ctx`, `path`, `opts` and `policy` in background.
+func StartStopPidfile(ctx context.Context, path string, opts PidfileOptions, policy Policy) *Operation {
+	return startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		result, err := StopPidfileWithContext(ctx, path, opts, policy)
+		return []StopResult{result}, err
+	})
+}
+
+// StartStopPipeline starts StopPipelineWithContext with `ctx`, `pid` and `policy` in background.
+func StartStopPipeline(ctx context.Context, pid int, policy Policy) *Operation {
+	return startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		return StopPipelineWithContext(ctx, pid, policy)
+	})
+}
+
+// PathOperation is a stop of the processes holding a path running in background, see StartStopPath.
+type PathOperation struct {
+	*Operation
+	results []PathStopResult
+}
+
+// StartStopPath starts StopPathWithContext with `ctx`, `path` and `policy` in background.
+func StartStopPath(ctx context.Context, path string, policy Policy) *PathOperation {
+	op := &PathOperation{}
+	op.Operation = startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		var err error
+		op.results, err = StopPathWithContext(ctx, path, policy)
+		return lo.Map(op.results, func(result PathStopResult, _ int) StopResult { return result.StopResult }), err
+	})
+	return op
+}
+
+// Wait waits for the operation to finish and returns results of every process and combined errors of failed stops.
+func (o *PathOperation) Wait() ([]PathStopResult, error) {
+	_, err := o.Operation.Wait()
+	return o.results, err
+}
+
+// GraphOperation is a stop of a StopGraph running in background, see StartStopGraph.
+type GraphOperation struct {
+	*Operation
+	results []GraphNodeResult
+}
+
+// StartStopGraph starts StopGraph.StopWithContext of `graph` with `ctx` in background.
+//
+// Operation.Cancel makes the nodes being stopped and the nodes not started yet skip their graceful stages.
+func StartStopGraph(ctx context.Context, graph StopGraph) *GraphOperation {
+	op := &GraphOperation{}
+	op.Operation = startOperation(ctx, func(ctx context.Context) ([]StopResult, error) {
+		var err error
+		op.results, err = graph.StopWithContext(ctx)
+		return lo.FlatMap(op.results, func(result GraphNodeResult, _ int) []StopResult { return result.Results }), err
+	})
+	return op
+}
+
+// Wait waits for the operation to finish and returns results of every node and the error of the graph.
+func (o *GraphOperation) Wait() ([]GraphNodeResult, error) {
+	_, err := o.Operation.Wait()
+	return o.results, err
+}
+
+// startOperation runs `fn` in background with a copy of `ctx` reporting events and force requests to the returned
+// operation.
+func startOperation(ctx context.Context, fn func(ctx context.Context) ([]StopResult, error)) *Operation {
+	op := &Operation{
+		force:   make(chan struct{}),
+		done:    make(chan struct{}),
+		queued:  make(chan struct{}, 1),
+		events:  make(chan ObserverEvent),
+		stopped: make(chan struct{}),
+	}
+	ctx = WithObserver(ctx, ObserverFunc(op.enqueue))
+	ctx = context.WithValue(ctx, forceKey{}, op.force)
+	go func() {
+		defer close(op.done)
+		op.results, op.err = fn(ctx)
+	}()
+	return op
+}
+
+// Events returns a channel of progress events of the operation, in order. The channel is closed after the operation
+// is done and all events are delivered.
+//
+// Events are buffered until received, so the channel does not need to be read before it is requested. Once requested,
+// it must be read until closed, or StopEvents must be called if the events are no longer needed.
+func (o *Operation) Events() <-chan ObserverEvent {
+	o.eventsOnce.Do(func() {
+		go o.deliver()
+	})
+	return o.events
+}
+
+// StopEvents stops delivery of the progress events, e.g. if the receiver of the channel returned by Events stopped
+// reading it. The events not received yet are dropped and the channel is closed.
+func (o *Operation) StopEvents() {
+	o.stopOnce.Do(func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.queue = nil
+		close(o.stopped)
+	})
+}
+
+// Done returns a channel which is closed when the operation is done.
+func (o *Operation) Done() <-chan struct{} {
+	return o.done
+}
+
+// Wait waits for the operation to finish and returns results of every process and combined errors of failed stops.
+func (o *Operation) Wait() ([]StopResult, error) {
+	<-o.done
+	return o.results, o.err
+}
+
+// Cancel skips the remaining graceful stages: the processes being stopped are killed by the kill stage of their policy,
+// or right away if the policy has no kill stage. Processes which are not stopped yet are killed as soon as their stop
+// starts.
+//
+// Cancel the context the operation was started with to abort the operation without killing the processes.
+func (o *Operation) Cancel() {
+	o.forceOnce.Do(func() {
+		close(o.force)
+	})
+}
+
+// enqueue buffers `event` for delivery, unless StopEvents was called. Used to implement Observer interface.
+func (o *Operation) enqueue(event ObserverEvent) {
+	o.mu.Lock()
+	select {
+	case <-o.stopped:
+		o.mu.Unlock()
+		return
+	default:
+	}
+	o.queue = append(o.queue, event)
+	o.mu.Unlock()
+	select {
+	case o.queued <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the buffered events to the events channel until the operation is done and the buffer is empty, or
+// StopEvents is called.
+func (o *Operation) deliver() {
+	defer close(o.events)
+	for {
+		o.mu.Lock()
+		queue := o.queue
+		o.queue = nil
+		o.mu.Unlock()
+		for _, event := range queue {
+			select {
+			case o.events <- event:
+			case <-o.stopped:
+				return
+			}
+		}
+		if len(queue) != 0 {
+			continue
+		}
+		select {
+		case <-o.stopped:
+			return
+		case <-o.queued:
+		case <-o.done:
+			o.mu.Lock()
+			empty := len(o.queue) == 0
+			o.mu.Unlock()
+			if empty {
+				return
+			}
+		}
+	}
+}
+
+// forceKey is a context key of the channel closed when the stop must skip to the kill stage.
+type forceKey struct{}
+
+// forceRequested returns true if the operation of `ctx` was cancelled with Operation.Cancel.
+func forceRequested(ctx context.Context) bool {
+	force, _ := ctx.Value(forceKey{}).(chan struct{})
+	if force == nil {
+		return false
+	}
+	select {
+	case <-force:
+		return true
+	default:
+		return false
+	}
+}
+
+// withForce returns a copy of `ctx` with which the stops skip their graceful stages as with Operation.Cancel when the
+// returned function is called or the operation of `ctx` is cancelled. The function must be called to release resources
+// when the stops are done.
+func withForce(ctx context.Context) (context.Context, func()) {
+	force := make(chan struct{})
+	var once sync.Once
+	forceFn := func() {
+		once.Do(func() {
+			close(force)
+		})
+	}
+	if parent, _ := ctx.Value(forceKey{}).(chan struct{}); parent != nil {
+		go func() {
+			select {
+			case <-parent:
+				forceFn()
+			case <-force:
+			}
+		}()
+	}
+	return context.WithValue(ctx, forceKey{}, force), forceFn
+}
+
+// withForceCancel returns a copy of `ctx` which is done when the operation of `ctx` is cancelled with
+// Operation.Cancel.
+func withForceCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	force, _ := ctx.Value(forceKey{}).(chan struct{})
+	if force == nil {
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-force:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,25 @@
+package terminator
+
+// PermissionVerdict is an outcome of a permission pre-check of an operation on a process.
+type PermissionVerdict struct {
+	PID int
+	// Allowed is set to true if the operation is expected to succeed.
+	Allowed bool
+	// Missing contains descriptions of the unmet requirements which make the operation fail.
+	Missing []string
+	// Hints contains descriptions of the conditions which can not be verified in advance but may still make the
+	// operation fail, e.g. seccomp filters or LSM policies.
+	Hints []string
+	// SameUser is set to true if the real or effective user ID of the current process matches the real or saved user
+	// ID of the target process.
+	SameUser bool
+	// CapKill is set to true if the current process has effective CAP_KILL capability.
+	CapKill bool
+	// CapSysAdmin is set to true if the current process has effective CAP_SYS_ADMIN capability.
+	CapSysAdmin bool
+	// PtraceScope is a value of kernel.yama.ptrace_scope sysctl, or -1 if Yama is not enabled. Affects access to
+	// the details of the other processes, e.g. by CaptureDiagnostics.
+	PtraceScope int
+	// LegacyTIOCSTI is a value of dev.tty.legacy_tiocsti sysctl, or -1 if the kernel does not have it (before 6.2).
+	LegacyTIOCSTI int
+}
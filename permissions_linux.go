@@ -0,0 +1,161 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Capability numbers, see linux/capability.h.
+const (
+	capKill     = 5
+	capSysAdmin = 21
+)
+
+// CanSignal checks whether the current process is permitted to send signals to the process with PID `pid`.
+//
+// The process can be signaled if it belongs to the same user or the current process has CAP_KILL capability.
+func CanSignal(pid int) (PermissionVerdict, error) {
+	verdict, err := newPermissionVerdict(pid)
+	if err != nil {
+		return verdict, errors.Wrapf(err, "Check permission to signal the process with PID %v", pid)
+	}
+	if !verdict.SameUser && !verdict.CapKill {
+		verdict.Missing = append(verdict.Missing, "CAP_KILL capability, as the process belongs to another user")
+	}
+	verdict.Allowed = len(verdict.Missing) == 0
+	return verdict, nil
+}
+
+// CanSendMessage checks whether the current process is permitted to write messages to the console of the process with
+// PID `pid`, see SendMessage.
+//
+// Injecting input with TIOCSTI requires write access to the terminal of the process, and CAP_SYS_ADMIN capability if
+// the terminal is not the controlling terminal of the current process or dev.tty.legacy_tiocsti sysctl is 0.
+func CanSendMessage(pid int) (PermissionVerdict, error) {
+	verdict, err := newPermissionVerdict(pid)
+	if err != nil {
+		return verdict, errors.Wrapf(err, "Check permission to write message to the process with PID %v", pid)
+	}
+
+	term, err := GetTerm(pid)
+	if err != nil {
+		verdict.Missing = append(verdict.Missing, "terminal, as the process has no controlling terminal")
+		return verdict, nil
+	}
+	if err := unix.Access(term, unix.W_OK); err != nil {
+		verdict.Missing = append(verdict.Missing, fmt.Sprintf("write access to the terminal %v", term))
+	}
+	if !verdict.CapSysAdmin {
+		if verdict.LegacyTIOCSTI == 0 {
+			verdict.Missing = append(verdict.Missing,
+				"CAP_SYS_ADMIN capability, as TIOCSTI is disabled by dev.tty.legacy_tiocsti sysctl")
+		} else if ownTerm, err := GetTerm(os.Getpid()); err != nil || ownTerm != term {
+			verdict.Missing = append(verdict.Missing,
+				fmt.Sprintf("CAP_SYS_ADMIN capability, as %v is not the controlling terminal of the current process", term))
+		}
+	}
+	verdict.Allowed = len(verdict.Missing) == 0
+	return verdict, nil
+}
+
+// newPermissionVerdict returns a verdict for the process with PID `pid` filled with credentials of the current process
+// and the system settings.
+func newPermissionVerdict(pid int) (PermissionVerdict, error) {
+	verdict := PermissionVerdict{
+		PID:           pid,
+		PtraceScope:   readSysctlInt("kernel/yama/ptrace_scope"),
+		LegacyTIOCSTI: readSysctlInt("dev/tty/legacy_tiocsti"),
+	}
+	target, err := readProcStatus(strconv.Itoa(pid))
+	if err != nil {
+		return verdict, err
+	}
+	self, err := readProcStatus("self")
+	if err != nil {
+		return verdict, err
+	}
+
+	// Uid line contains real, effective, saved and filesystem user ID's.
+	targetUids := strings.Fields(target["Uid"])
+	if len(targetUids) < 3 {
+		return verdict, errors.Newf("Malformed Uid of the process with PID %v", pid)
+	}
+	for _, uid := range []int{unix.Getuid(), unix.Geteuid()} {
+		if strconv.Itoa(uid) == targetUids[0] || strconv.Itoa(uid) == targetUids[2] {
+			verdict.SameUser = true
+		}
+	}
+	caps, err := strconv.ParseUint(self["CapEff"], 16, 64)
+	if err == nil {
+		verdict.CapKill = caps&(1<<capKill) != 0
+		verdict.CapSysAdmin = caps&(1<<capSysAdmin) != 0
+	}
+
+	if self["Seccomp"] == "2" {
+		verdict.Hints = append(verdict.Hints, "the current process runs under a seccomp filter which may block the operation")
+	}
+	if label := lsmLabel(); label != "" {
+		verdict.Hints = append(verdict.Hints, fmt.Sprintf("the current process is confined by LSM label %q", label))
+	}
+	return verdict, nil
+}
+
+// readProcStatus returns key-value pairs of /proc/<pid>/status, where `pid` is PID or "self".
+func readProcStatus(pid string) (map[string]string, error) {
+	content, err := os.ReadFile(procPath(pid, "status"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Read status of the process %v", pid)
+	}
+	status := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			status[key] = strings.TrimSpace(value)
+		}
+	}
+	return status, nil
+}
+
+// readSysctlInt returns integer value of the sysctl with path `name` under /proc/sys, or -1 if it is not available.
+func readSysctlInt(name string) int {
+	content, err := os.ReadFile(procPath("sys", name))
+	if err != nil {
+		return -1
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// lsmLabel returns the security label of the current process if it is confined by enforcing SELinux or AppArmor
+// policy, or empty string otherwise.
+func lsmLabel() string {
+	if content, err := os.ReadFile(procPath("self", "attr", "apparmor", "current")); err == nil {
+		label := strings.TrimSpace(strings.TrimRight(string(content), "\x00"))
+		// Label has a mode suffix, e.g. "profile (enforce)". Profiles in complain mode do not deny anything.
+		if label != "" && label != "unconfined" && !strings.HasSuffix(label, "(complain)") {
+			return label
+		}
+		return ""
+	}
+	if enforce, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil && strings.TrimSpace(string(enforce)) == "1" {
+		content, err := os.ReadFile(procPath("self", "attr", "current"))
+		label := strings.TrimSpace(strings.TrimRight(string(content), "\x00"))
+		if err == nil && !strings.Contains(label, "unconfined_t") {
+			return label
+		}
+	}
+	return ""
+}
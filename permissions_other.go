@@ -0,0 +1,19 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"github.com/cockroachdb/errors"
+)
+
+// CanSignal is only implemented on Linux, returns ErrNotSupported.
+func CanSignal(pid int) (PermissionVerdict, error) {
+	verdict := PermissionVerdict{PID: pid, PtraceScope: -1, LegacyTIOCSTI: -1}
+	return verdict, errors.Wrapf(ErrNotSupported, "Check permission to signal the process with PID %v", pid)
+}
+
+// CanSendMessage is only implemented on Linux, returns ErrNotSupported.
+func CanSendMessage(pid int) (PermissionVerdict, error) {
+	verdict := PermissionVerdict{PID: pid, PtraceScope: -1, LegacyTIOCSTI: -1}
+	return verdict, errors.Wrapf(ErrNotSupported, "Check permission to write message to the process with PID %v", pid)
+}
@@ -0,0 +1,218 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// pidfileClockSlack is how much later than the pidfile modification time a process can start to still be considered
+// the one which wrote it.
+const pidfileClockSlack = time.Second * 2
+
+// PidfileOptions are options of FromPidfile and StopPidfile.
+type PidfileOptions struct {
+	// Exe is an expected executable of the process: an absolute path or a name without extension. Not checked if empty.
+	Exe string
+	// Cmdline is an expected substring of the command line of the process. Not checked if empty.
+	Cmdline string
+	// RemoveStale is set to true to remove the pidfile if it is stale or becomes stale after the process is stopped.
+	RemoveStale bool
+}
+
+// ErrStalePidfile indicates that a pidfile refers to a process which is not running, or the PID was reused by another
+// process.
+type ErrStalePidfile struct {
+	Path   string
+	PID    int
+	Reason string
+}
+
+// Error is used to implement error interface.
+func (e ErrStalePidfile) Error() string {
+	return fmt.Sprintf("The pidfile %v with PID %v is stale: %v", e.Path, e.PID, e.Reason)
+}
+
+// newErrStalePidfile returns new ErrStalePidfile with pidfile path `path`, PID `pid` and reason `reason`.
+func newErrStalePidfile(path string, pid int, reason string) ErrStalePidfile {
+	return ErrStalePidfile{Path: path, PID: pid, Reason: reason}
+}
+
+// ErrPidfileLocked indicates that another stop of the process from the pidfile is in progress.
+type ErrPidfileLocked struct {
+	Path string
+}
+
+// Error is used to implement error interface.
+func (e ErrPidfileLocked) Error() string {
+	return fmt.Sprintf("The pidfile %v is locked by another stop operation", e.Path)
+}
+
+// newErrPidfileLocked returns new ErrPidfileLocked with pidfile path `path`.
+func newErrPidfileLocked(path string) ErrPidfileLocked {
+	return ErrPidfileLocked{Path: path}
+}
+
+// FromPidfile returns PID read from the pidfile with path `path` after validating it with options `opts`.
+//
+// Among others, can return ErrStalePidfile error defined in this package. If `opts.RemoveStale` is set, the stale
+// pidfile is removed.
+func FromPidfile(path string, opts PidfileOptions) (int, error) {
+	pid, err := validatePidfile(path, opts)
+	if errors.HasType(err, ErrStalePidfile{}) && opts.RemoveStale {
+		_ = os.Remove(path)
+	}
+	return pid, err
+}
+
+// validatePidfile returns PID read from the pidfile with path `path` after validating it with options `opts`.
+func validatePidfile(path string, opts PidfileOptions) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Read pidfile %v", path)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return 0, errors.Newf("Read pidfile %v: Malformed content %q", path, strings.TrimSpace(string(content)))
+	}
+
+	proc, err := process.NewProcess(int32(pid))
+	if errors.Is(err, process.ErrorProcessNotRunning) || (err == nil && isZombie(pid)) {
+		return pid, newErrStalePidfile(path, pid, "process is not running")
+	}
+	if err != nil {
+		return pid, errors.Wrapf(err, "Read pidfile %v", path)
+	}
+
+	// The PID was reused if the process started after the pidfile was written.
+	info, statErr := os.Stat(path)
+	createTime, createErr := proc.CreateTime()
+	if statErr == nil && createErr == nil && time.UnixMilli(createTime).After(info.ModTime().Add(pidfileClockSlack)) {
+		return pid, newErrStalePidfile(path, pid, "process started after the pidfile was written")
+	}
+
+	if opts.Exe != "" {
+		exe, err := proc.Exe()
+		if err != nil {
+			return pid, errors.Wrapf(err, "Read pidfile %v: Get executable of the process with PID %v", path, pid)
+		}
+		match := exeName(exe) == exeName(opts.Exe)
+		if filepath.IsAbs(opts.Exe) {
+			match = exe == opts.Exe
+		}
+		if !match {
+			return pid, newErrStalePidfile(path, pid, fmt.Sprintf("executable %v does not match %v", exe, opts.Exe))
+		}
+	}
+	if opts.Cmdline != "" {
+		cmdline, err := proc.Cmdline()
+		if err != nil {
+			return pid, errors.Wrapf(err, "Read pidfile %v: Get command line of the process with PID %v", path, pid)
+		}
+		if !strings.Contains(cmdline, opts.Cmdline) {
+			return pid, newErrStalePidfile(path, pid, fmt.Sprintf("command line does not contain %q", opts.Cmdline))
+		}
+	}
+	return pid, nil
+}
+
+// PidfileSelector selects the process from the pidfile Path, see FromPidfile.
+type PidfileSelector struct {
+	Path    string
+	Options PidfileOptions
+}
+
+// Select is used to implement Selector interface.
+func (s PidfileSelector) Select(ctx context.Context) ([]int, error) {
+	pid, err := FromPidfile(s.Path, s.Options)
+	if err != nil {
+		return nil, err
+	}
+	return []int{pid}, nil
+}
+
+// String is used to implement Selector interface.
+func (s PidfileSelector) String() string {
+	return fmt.Sprintf("pidfile %v", s.Path)
+}
+
+// StopPidfile is the same as StopPidfileWithContext with background context.
+func StopPidfile(path string, opts PidfileOptions, policy Policy) (StopResult, error) {
+	return StopPidfileWithContext(context.Background(), path, opts, policy)
+}
+
+// StopPidfileWithContext stops the process from the pidfile with path `path` validated with options `opts` (see
+// FromPidfile) with `policy` using context `ctx`.
+//
+// A lock file "<path>.lock" is held during the operation, so concurrent stops of the same pidfile do not escalate the
+// same process at once. Among others, can return ErrPidfileLocked and ErrStalePidfile errors defined in this package.
+//
+// If `opts.RemoveStale` is set, the pidfile is removed after the stop is confirmed, unless the process already did it
+// or wrote another PID.
+func StopPidfileWithContext(ctx context.Context, path string, opts PidfileOptions, policy Policy) (StopResult, error) {
+	unlock, err := lockPidfile(path)
+	if err != nil {
+		return StopResult{}, errors.Wrapf(err, "Stop process from pidfile %v", path)
+	}
+	defer unlock()
+
+	pid, err := FromPidfile(path, opts)
+	if err != nil {
+		return StopResult{PID: pid}, errors.Wrapf(err, "Stop process from pidfile %v", path)
+	}
+	emit(ctx, ObserverEvent{Kind: EventTargetResolved, PIDs: []int{pid}, Target: PidfileSelector{Path: path}.String()})
+	result, err := StopWithContext(ctx, pid, policy)
+	if err != nil {
+		return result, errors.Wrapf(err, "Stop process from pidfile %v", path)
+	}
+	if result.Stopped && opts.RemoveStale {
+		if content, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(content)) == strconv.Itoa(pid) {
+			if err := os.Remove(path); err != nil {
+				return result, errors.Wrapf(err, "Stop process from pidfile %v: Remove stale pidfile", path)
+			}
+		}
+	}
+	return result, nil
+}
+
+// lockPidfile acquires exclusive lock file for the pidfile with path `path` without waiting. Returns function to
+// release the lock.
+//
+// Returns ErrPidfileLocked if the lock is held by someone else.
+func lockPidfile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	for {
+		file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Lock pidfile %v", path)
+		}
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "Lock pidfile %v", path)
+		}
+		if !locked {
+			_ = file.Close()
+			return nil, newErrPidfileLocked(path)
+		}
+		// The previous holder could remove the lock file between open and lock, start over in this case.
+		fileInfo, statErr := file.Stat()
+		pathInfo, pathErr := os.Stat(lockPath)
+		if statErr != nil || pathErr != nil || !os.SameFile(fileInfo, pathInfo) {
+			_ = file.Close()
+			continue
+		}
+		return func() {
+			// Remove before releasing the lock, so the next holder never locks a removed file.
+			_ = os.Remove(lockPath)
+			_ = file.Close()
+		}, nil
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package terminator
+
+import (
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile acquires exclusive advisory lock on `file` without waiting. Returns false if it is locked by someone
+// else.
+func tryLockFile(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, errors.Wrap(err, "Lock file")
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package terminator
+
+import (
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/windows"
+)
+
+// tryLockFile acquires exclusive lock on `file` without waiting. Returns false if it is locked by someone else.
+//
+// The lock is released when the file is closed.
+func tryLockFile(file *os.File) (bool, error) {
+	overlapped := windows.Overlapped{}
+	err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, &overlapped)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, errors.Wrap(err, "Lock file")
+}
@@ -0,0 +1,93 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+)
+
+// Pipeline is a set of processes connected by pipes, such as a shell pipeline "producer | filter | sink".
+type Pipeline struct {
+	// Stages are groups of processes in data flow order: processes of a stage read from the previous stages and write to
+	// the next ones. The first stage contains producers, the last one contains sinks.
+	Stages [][]int
+}
+
+// PIDs returns PID's of all processes of the pipeline in data flow order.
+func (p Pipeline) PIDs() []int {
+	return slices.Concat(p.Stages...)
+}
+
+// StopPipeline is the same as StopPipelineWithContext with background context.
+func StopPipeline(pid int, policy Policy) ([]StopResult, error) {
+	return StopPipelineWithContext(context.Background(), pid, policy)
+}
+
+// StopPipelineWithContext stops the pipeline containing the process with PID `pid` (see GetPipeline) upstream first
+// using context `ctx`, so the data buffered in the pipes is not lost.
+//
+// Producers are stopped with `policy`. Processes of each next stage are given the grace period of the first stage of
+// `policy` to drain their input and exit on end of file, and the ones still running after that are stopped with
+// `policy`.
+//
+// Returns results of every process in data flow order and combined errors of failed stops. Processes which exited on
+// their own have no stage results.
+func StopPipelineWithContext(ctx context.Context, pid int, policy Policy) ([]StopResult, error) {
+	pipeline, err := GetPipeline(pid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Stop pipeline of PID %v", pid)
+	}
+	emit(ctx, ObserverEvent{Kind: EventTargetResolved, PIDs: pipeline.PIDs(), Target: fmt.Sprintf("pipeline of pid %v", pid)})
+
+	if ctx, err = checkGuard(ctx, "stop pipeline", pipeline.PIDs()); err != nil {
+		return nil, errors.Wrapf(err, "Stop pipeline of PID %v", pid)
+	}
+
+	eofGrace := lo.FirstOrEmpty(policy.Stages).Grace
+	results := []StopResult{}
+	var result error
+	for i, stage := range pipeline.Stages {
+		if i > 0 {
+			// Let the stage drain the input and exit on end of file.
+			exited := waitForPids(ctx, stage, eofGrace)
+			running := []int{}
+			for idx, stagePid := range stage {
+				if exited[idx] {
+					results = append(results, StopResult{PID: stagePid, Stopped: true})
+				} else {
+					running = append(running, stagePid)
+				}
+			}
+			stage = running
+		}
+		if ctx.Err() != nil {
+			return results, errors.Wrapf(ctx.Err(), "Stop pipeline of PID %v", pid)
+		}
+		stageResults, err := stopPids(ctx, stage, policy)
+		results = append(results, stageResults...)
+		result = errors.CombineErrors(result, err)
+	}
+	return results, errors.Wrapf(result, "Stop pipeline of PID %v", pid)
+}
+
+// waitForPids waits up to `timeout` for processes `pids` to stop, concurrently, using context `ctx`. Returns whether
+// each of the processes stopped, in the order of `pids`.
+func waitForPids(ctx context.Context, pids []int, timeout time.Duration) []bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stopped := make([]bool, len(pids))
+	var wg sync.WaitGroup
+	for i, pid := range pids {
+		wg.Go(func() {
+			stopped[i] = waitForProcStop(ctx, pid)
+		})
+	}
+	wg.Wait()
+	return stopped
+}
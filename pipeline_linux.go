@@ -0,0 +1,115 @@
+//go:build linux
+
+package terminator
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// GetPipeline returns the pipeline containing the process with PID `pid`.
+//
+// Processes are connected if standard output of one of them and standard input of another one are the same pipe, as
+// in shell pipelines. The current process is never included.
+//
+// Pipes of processes of other users are not visible without root privilegies.
+func GetPipeline(pid int) (Pipeline, error) {
+	entries, err := os.ReadDir(procPath())
+	if err != nil {
+		return Pipeline{}, errors.Wrapf(err, "Get pipeline of PID %v", pid)
+	}
+
+	// Pipe inode to PID's of processes reading it as standard input or writing to it as standard output.
+	readers := map[string][]int{}
+	writers := map[string][]int{}
+	stdin := map[int]string{}
+	stdout := map[int]string{}
+	self := os.Getpid()
+	for _, entry := range entries {
+		procPid, err := strconv.Atoi(entry.Name())
+		if err != nil || procPid == self {
+			continue
+		}
+		if inode, ok := pipeInode(procPid, 0); ok {
+			readers[inode] = append(readers[inode], procPid)
+			stdin[procPid] = inode
+		}
+		if inode, ok := pipeInode(procPid, 1); ok {
+			writers[inode] = append(writers[inode], procPid)
+			stdout[procPid] = inode
+		}
+	}
+	if _, err := os.Stat(procPath(strconv.Itoa(pid))); err != nil {
+		return Pipeline{}, errors.Wrapf(err, "Get pipeline of PID %v", pid)
+	}
+
+	// Collect processes connected to `pid` in both directions.
+	upstream := map[int][]int{}
+	downstream := map[int][]int{}
+	members := map[int]bool{pid: true}
+	queue := []int{pid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if inode, ok := stdin[current]; ok {
+			upstream[current] = writers[inode]
+		}
+		if inode, ok := stdout[current]; ok {
+			downstream[current] = readers[inode]
+		}
+		for _, next := range slices.Concat(upstream[current], downstream[current]) {
+			if !members[next] {
+				members[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Stage of a process is the length of the longest path from a producer to it.
+	depth := map[int]int{}
+	var walk func(pid int, visiting map[int]bool) int
+	walk = func(pid int, visiting map[int]bool) int {
+		if d, ok := depth[pid]; ok {
+			return d
+		}
+		if visiting[pid] {
+			// Pipes form a cycle, break it here.
+			return 0
+		}
+		visiting[pid] = true
+		d := 0
+		for _, up := range upstream[pid] {
+			if up != pid {
+				d = max(d, walk(up, visiting)+1)
+			}
+		}
+		delete(visiting, pid)
+		depth[pid] = d
+		return d
+	}
+	pipeline := Pipeline{}
+	for _, member := range slices.Sorted(maps.Keys(members)) {
+		d := walk(member, map[int]bool{})
+		for len(pipeline.Stages) <= d {
+			pipeline.Stages = append(pipeline.Stages, []int{})
+		}
+		pipeline.Stages[d] = append(pipeline.Stages[d], member)
+	}
+	return pipeline, nil
+}
+
+// pipeInode returns inode of the pipe the file descriptor `fd` of the process with PID `pid` refers to, and true, or
+// false if it is not a pipe.
+func pipeInode(pid int, fd int) (string, bool) {
+	target, err := os.Readlink(procPath(strconv.Itoa(pid), "fd", strconv.Itoa(fd)))
+	if err != nil {
+		return "", false
+	}
+	inode, ok := strings.CutPrefix(target, "pipe:[")
+	return strings.TrimSuffix(inode, "]"), ok
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"github.com/cockroachdb/errors"
+)
+
+// GetPipeline is only implemented on Linux, returns ErrNotSupported.
+func GetPipeline(pid int) (Pipeline, error) {
+	return Pipeline{}, errors.Wrapf(ErrNotSupported, "Get pipeline of PID %v", pid)
+}
@@ -0,0 +1,222 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ProcIdentity identifies a process across PID reuse.
+type ProcIdentity struct {
+	PID int `json:"pid"`
+	// CreateTime is a creation time of the process in milliseconds since the epoch.
+	CreateTime int64 `json:"create_time"`
+	// Exe is an executable of the process. Empty if it could not be read.
+	Exe string `json:"exe,omitempty"`
+	// Cmdline is a command line of the process. Empty if it could not be read.
+	Cmdline []string `json:"cmdline,omitempty"`
+}
+
+// PlanTarget is a process a plan stops, with the identity recorded at planning time. Empty Exe and Cmdline are not
+// checked on execution.
+type PlanTarget struct {
+	ProcIdentity
+	// Step is an order of the stop: targets with lower steps are stopped first, targets with equal steps are stopped
+	// concurrently.
+	Step   int    `json:"step"`
+	Policy Policy `json:"policy"`
+}
+
+// Plan is a serializable description of a stop, resolved in advance. See ExecutePlan.
+type Plan struct {
+	// Description is a human readable description of what was planned, e.g. selector.
+	Description string       `json:"description"`
+	Created     time.Time    `json:"created"`
+	Targets     []PlanTarget `json:"targets"`
+}
+
+// ErrIdentityMismatch indicates that the process with PID of a plan target is not the process the plan was made for,
+// e.g. because the PID was reused.
+type ErrIdentityMismatch struct {
+	PID    int
+	Reason string
+}
+
+// Error is used to implement error interface.
+func (e ErrIdentityMismatch) Error() string {
+	return fmt.Sprintf("The process with PID %v is not the planned one: %v", e.PID, e.Reason)
+}
+
+// newErrIdentityMismatch returns new ErrIdentityMismatch with PID `pid` and reason `reason`.
+func newErrIdentityMismatch(pid int, reason string) ErrIdentityMismatch {
+	return ErrIdentityMismatch{PID: pid, Reason: reason}
+}
+
+// PlanSelector is the same as PlanSelectorWithContext with background context.
+func PlanSelector(sel Selector, policy Policy) (Plan, error) {
+	return PlanSelectorWithContext(context.Background(), sel, policy)
+}
+
+// PlanSelectorWithContext returns a plan to stop all processes matched by selector `sel` with `policy` concurrently
+// using context `ctx`, without stopping anything.
+//
+// Processes which exit while planning are left out.
+func PlanSelectorWithContext(ctx context.Context, sel Selector, policy Policy) (Plan, error) {
+	pids, err := sel.Select(ctx)
+	if err != nil {
+		return Plan{}, errors.Wrapf(err, "Plan stop of processes matching %v", sel)
+	}
+	plan, err := newPlan(ctx, sel.String(), pids, func(int) int { return 0 }, policy)
+	return plan, errors.Wrapf(err, "Plan stop of processes matching %v", sel)
+}
+
+// PlanTree is the same as PlanTreeWithContext with background context.
+func PlanTree(pid int, withRoot bool, policy Policy) (Plan, error) {
+	return PlanTreeWithContext(context.Background(), pid, withRoot, policy)
+}
+
+// PlanTreeWithContext returns a plan to stop descendants of the process with PID `pid` with `policy` using context
+// `ctx`, deepest first, without stopping anything. Processes of the same depth are stopped concurrently.
+//
+// If the `withRoot` argument is set to true, the root process is stopped last.
+func PlanTreeWithContext(ctx context.Context, pid int, withRoot bool, policy Policy) (Plan, error) {
+	snapshot, err := NewProcSnapshot()
+	if err != nil {
+		return Plan{}, errors.Wrapf(err, "Plan stop of tree of the process with PID %v", pid)
+	}
+	pids := snapshot.FlatChildTree(pid, withRoot)
+	depths := map[int]int{pid: 0}
+	maxDepth := 0
+	// FlatChildTree returns descendants deepest first, so walk in reverse to see parents before children.
+	for _, child := range slices.Backward(pids) {
+		if parent, ok := snapshot.Parent(child); ok && child != pid {
+			depths[child] = depths[parent] + 1
+			maxDepth = max(maxDepth, depths[child])
+		}
+	}
+	plan, err := newPlan(ctx, fmt.Sprintf("tree of pid %v", pid), pids, func(pid int) int {
+		return maxDepth - depths[pid]
+	}, policy)
+	return plan, errors.Wrapf(err, "Plan stop of tree of the process with PID %v", pid)
+}
+
+// newPlan returns a plan with description `description` to stop processes `pids` with `policy` in order of steps
+// returned by `step`, using context `ctx`.
+func newPlan(ctx context.Context, description string, pids []int, step func(pid int) int, policy Policy) (Plan, error) {
+	plan := Plan{Description: description, Created: time.Now(), Targets: []PlanTarget{}}
+	for _, pid := range pids {
+		identity, err := processIdentity(ctx, pid)
+		if errors.Is(err, process.ErrorProcessNotRunning) {
+			continue
+		}
+		if err != nil {
+			return plan, err
+		}
+		plan.Targets = append(plan.Targets, PlanTarget{
+			ProcIdentity: identity,
+			Step:         step(pid),
+			Policy:       policy.resolve(pid),
+		})
+	}
+	slices.SortStableFunc(plan.Targets, func(a, b PlanTarget) int { return a.Step - b.Step })
+	return plan, nil
+}
+
+// processIdentity returns identity of the process with PID `pid` using context `ctx`.
+func processIdentity(ctx context.Context, pid int) (ProcIdentity, error) {
+	target := ProcIdentity{PID: pid}
+	proc, err := process.NewProcessWithContext(ctx, int32(pid))
+	if err != nil {
+		return target, errors.Wrapf(err, "Get identity of the process with PID %v", pid)
+	}
+	target.CreateTime, err = proc.CreateTimeWithContext(ctx)
+	if err != nil {
+		return target, errors.Wrapf(err, "Get identity of the process with PID %v", pid)
+	}
+	// Executable and command line may be unreadable without privileges, the creation time is enough to detect reuse.
+	target.Exe, _ = proc.ExeWithContext(ctx)
+	target.Cmdline, _ = proc.CmdlineSliceWithContext(ctx)
+	return target, nil
+}
+
+// validate returns ErrIdentityMismatch if the process with PID of the target is not the planned one, using context
+// `ctx`. Returns process.ErrorProcessNotRunning if the process exited or is a zombie.
+func (t PlanTarget) validate(ctx context.Context) error {
+	actual, err := processIdentity(ctx, t.PID)
+	if err != nil {
+		return err
+	}
+	if actual.CreateTime != t.CreateTime {
+		return newErrIdentityMismatch(t.PID, "creation time differs")
+	}
+	// Executable of a zombie can not be read, but it is stopped anyway.
+	if isZombie(t.PID) {
+		return process.ErrorProcessNotRunning
+	}
+	if t.Exe != "" && actual.Exe != t.Exe {
+		return newErrIdentityMismatch(t.PID, fmt.Sprintf("executable %v differs from %v", actual.Exe, t.Exe))
+	}
+	if len(t.Cmdline) != 0 && !slices.Equal(actual.Cmdline, t.Cmdline) {
+		return newErrIdentityMismatch(t.PID, "command line differs")
+	}
+	return nil
+}
+
+// ExecutePlan is the same as ExecutePlanWithContext with background context.
+func ExecutePlan(plan Plan) ([]StopResult, error) {
+	return ExecutePlanWithContext(context.Background(), plan)
+}
+
+// ExecutePlanWithContext stops the targets of `plan` step by step using context `ctx`.
+//
+// The identity of every target is validated right before it's step. Targets which are not the planned processes are
+// not touched and reported with ErrIdentityMismatch error, targets which already exited are considered stopped.
+//
+// Returns results in the order of the plan targets and combined errors of failed stops.
+func ExecutePlanWithContext(ctx context.Context, plan Plan) ([]StopResult, error) {
+	results := lo.Map(plan.Targets, func(target PlanTarget, _ int) StopResult { return StopResult{PID: target.PID} })
+	pids := lo.Map(plan.Targets, func(target PlanTarget, _ int) int { return target.PID })
+	ctx, err := checkGuard(ctx, "execute plan", pids)
+	if err != nil {
+		return results, errors.Wrapf(err, "Execute plan of %v", plan.Description)
+	}
+	emit(ctx, ObserverEvent{Kind: EventTargetResolved, PIDs: pids, Target: plan.Description})
+	errs := make([]error, len(plan.Targets))
+	steps := lo.Uniq(lo.Map(plan.Targets, func(target PlanTarget, _ int) int { return target.Step }))
+	slices.Sort(steps)
+	for _, step := range steps {
+		var wg sync.WaitGroup
+		for i, target := range plan.Targets {
+			if target.Step != step {
+				continue
+			}
+			wg.Go(func() {
+				err := target.validate(ctx)
+				switch {
+				case errors.Is(err, process.ErrorProcessNotRunning):
+					results[i].Stopped = true
+				case err != nil:
+					errs[i] = err
+				default:
+					results[i], errs[i] = StopWithContext(ctx, target.PID, target.Policy)
+				}
+			})
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	result := ctx.Err()
+	for _, err := range errs {
+		result = errors.CombineErrors(result, err)
+	}
+	return results, errors.Wrapf(result, "Execute plan of %v", plan.Description)
+}
@@ -0,0 +1,84 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"syscall"
+
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// ByPort is the same as ByPortWithContext with background context.
+func ByPort(proto string, port int) ([]int, error) {
+	return ByPortWithContext(context.Background(), proto, port)
+}
+
+// ByPortWithContext returns PID's of the processes owning sockets bound to local port `port` using context `ctx`:
+// listening TCP sockets or bound UDP sockets.
+//
+// `proto` is one of "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6" or empty string for all of them.
+//
+// The current process is never returned. Sockets of processes of other users may be not visible without root
+// privilegies.
+func ByPortWithContext(ctx context.Context, proto string, port int) ([]int, error) {
+	kind := proto
+	if kind == "" {
+		kind = "inet"
+	}
+	if !slices.Contains([]string{"inet", "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6"}, kind) {
+		return nil, errors.Newf("Find processes by port %v: Unknown protocol %q", port, proto)
+	}
+	conns, err := net.ConnectionsWithContext(ctx, kind)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Find processes by port %v", port)
+	}
+
+	self := os.Getpid()
+	pids := []int{}
+	for _, conn := range conns {
+		if int(conn.Laddr.Port) != port || conn.Pid <= 0 || int(conn.Pid) == self {
+			continue
+		}
+		// Only TCP sockets in LISTEN state own the port, others are connections to it or from it.
+		if conn.Type == syscall.SOCK_STREAM && conn.Status != "LISTEN" {
+			continue
+		}
+		pids = append(pids, int(conn.Pid))
+	}
+	slices.Sort(pids)
+	return slices.Compact(pids), nil
+}
+
+// PortSelector selects processes owning local port Port, see ByPort.
+type PortSelector struct {
+	// Proto is one of "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6" or empty string for all of them.
+	Proto string
+	Port  int
+}
+
+// Select is used to implement Selector interface.
+func (s PortSelector) Select(ctx context.Context) ([]int, error) {
+	return ByPortWithContext(ctx, s.Proto, s.Port)
+}
+
+// String is used to implement Selector interface.
+func (s PortSelector) String() string {
+	if s.Proto == "" {
+		return fmt.Sprintf("port %v", s.Port)
+	}
+	return fmt.Sprintf("port %v/%v", s.Port, s.Proto)
+}
+
+// StopPort is the same as StopPortWithContext with background context.
+func StopPort(proto string, port int, policy Policy) ([]StopResult, error) {
+	return StopPortWithContext(context.Background(), proto, port, policy)
+}
+
+// StopPortWithContext stops all processes owning local port `port` of protocol `proto` (see ByPort) with `policy`
+// using context `ctx`.
+func StopPortWithContext(ctx context.Context, proto string, port int, policy Policy) ([]StopResult, error) {
+	return StopSelectorWithContext(ctx, PortSelector{Proto: proto, Port: port}, policy)
+}
@@ -0,0 +1,95 @@
+package terminator
+
+import (
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrProcEventsUnavailable indicates that the process event stream can not be used on this system, for example because
+// of insufficient privileges or unsupported platform.
+var ErrProcEventsUnavailable = errors.New("Process event stream is not available")
+
+// ProcEventKind is a kind of process event.
+type ProcEventKind int
+
+const (
+	// ProcEventFork indicates that a new process or thread was created.
+	ProcEventFork ProcEventKind = iota + 1
+	// ProcEventExec indicates that a process called exec.
+	ProcEventExec
+	// ProcEventExit indicates that a process or thread exited.
+	ProcEventExit
+	// ProcEventUID indicates that a process changed it's user ID.
+	ProcEventUID
+	// ProcEventOverflow indicates that some events were dropped by the kernel because they were not read in time.
+	// Consumers relying on a complete picture should rescan the processes.
+	ProcEventOverflow
+)
+
+// String is used to implement fmt.Stringer interface.
+func (k ProcEventKind) String() string {
+	switch k {
+	case ProcEventFork:
+		return "fork"
+	case ProcEventExec:
+		return "exec"
+	case ProcEventExit:
+		return "exit"
+	case ProcEventUID:
+		return "uid"
+	case ProcEventOverflow:
+		return "overflow"
+	default:
+		return "unknown"
+	}
+}
+
+// ProcEvent is a process lifecycle notification received from the kernel.
+type ProcEvent struct {
+	Kind ProcEventKind
+	// PID is a process (thread group) identifier.
+	PID int
+	// TID is a thread identifier. Equals PID for the main thread of the process.
+	TID int
+	// ParentPID is a parent process identifier. Set for ProcEventFork and ProcEventExit (if reported by the kernel).
+	ParentPID int
+	// ExitCode is a raw wait status of the exited thread. Set for ProcEventExit.
+	ExitCode int
+	// ExitSignal is a signal the parent receives on exit. Set for ProcEventExit.
+	ExitSignal int
+	// UID is a real user ID. Set for ProcEventUID.
+	UID int
+	// EUID is an effective user ID. Set for ProcEventUID.
+	EUID int
+}
+
+// IsThread returns true if the event is related to a non-main thread rather than to a process.
+func (e ProcEvent) IsThread() bool {
+	return e.TID != e.PID
+}
+
+// ProcEventListener is a subscription to the process event stream.
+type ProcEventListener struct {
+	events    chan ProcEvent
+	done      chan struct{}
+	closeOnce sync.Once
+	closeFn   func() error
+}
+
+// Events returns a channel of process events.
+//
+// The channel is closed after the listener is closed or the context used to create it is done.
+func (l *ProcEventListener) Events() <-chan ProcEvent {
+	return l.events
+}
+
+// Close stops listening for process events.
+func (l *ProcEventListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.done)
+		err = l.closeFn()
+	})
+	return errors.Wrap(err, "Close process event listener")
+}
@@ -0,0 +1,319 @@
+//go:build linux
+
+package terminator
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Proc connector constants.
+//
+// See linux/connector.h and linux/cn_proc.h.
+const (
+	cnIdxProc         uint32 = 0x1
+	cnValProc         uint32 = 0x1
+	procCnMcastListen uint32 = 0x1
+	procCnMcastIgnore uint32 = 0x2
+
+	procEventFork uint32 = 0x00000001
+	procEventExec uint32 = 0x00000002
+	procEventUID  uint32 = 0x00000004
+	procEventExit uint32 = 0x80000000
+
+	// Size of struct cn_msg without payload.
+	sizeofCnMsg = 20
+	// Offset of event_data union in struct proc_event.
+	procEventDataOffset = 16
+)
+
+// ListenProcEvents subscribes to the kernel proc connector and returns a listener receiving fork, exec, exit and user ID
+// change events of all processes in the system until `ctx` is done or the listener is closed.
+//
+// Requires CAP_NET_ADMIN capability (e.g. run as sudo) and the initial network namespace. Returns error marked as
+// ErrProcEventsUnavailable if the subscription failed.
+//
+// The kernel generates the events system-wide while there are subscribers, so close the listener when it is no longer
+// needed.
+func ListenProcEvents(ctx context.Context) (*ProcEventListener, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "Listen process events")
+	default:
+	}
+
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, markProcEventsUnavailable(errors.Wrap(err, "Listen process events: Create netlink socket"))
+	}
+	// Wrap the socket in a file to use the runtime poller, so Close unblocks pending reads.
+	file := os.NewFile(uintptr(fd), "proc-connector")
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		_ = file.Close()
+		return nil, markProcEventsUnavailable(errors.Wrap(err, "Listen process events: Bind netlink socket"))
+	}
+	if err := sendProcCnOp(fd, procCnMcastListen); err != nil {
+		_ = file.Close()
+		return nil, markProcEventsUnavailable(errors.Wrap(err, "Listen process events: Subscribe to proc connector"))
+	}
+
+	listener := &ProcEventListener{
+		events: make(chan ProcEvent, 256),
+		done:   make(chan struct{}),
+		closeFn: func() error {
+			// The kernel keeps generating the events while there are subscribers, even if their sockets are closed.
+			err := sendProcCnOp(fd, procCnMcastIgnore)
+			return errors.CombineErrors(errors.Wrap(err, "Unsubscribe from proc connector"), file.Close())
+		},
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = listener.Close()
+		case <-listener.done:
+		}
+	}()
+	go readProcEvents(file, listener)
+
+	return listener, nil
+}
+
+// markProcEventsUnavailable marks `err` as ErrProcEventsUnavailable.
+func markProcEventsUnavailable(err error) error {
+	return errors.Mark(err, ErrProcEventsUnavailable)
+}
+
+// sendProcCnOp sends PROC_CN_MCAST_LISTEN or PROC_CN_MCAST_IGNORE operation `op` to the proc connector over the
+// netlink socket `fd`.
+func sendProcCnOp(fd int, op uint32) error {
+	buf := make([]byte, unix.SizeofNlMsghdr+sizeofCnMsg+4)
+	// struct nlmsghdr.
+	binary.NativeEndian.PutUint32(buf[0:], uint32(len(buf)))
+	binary.NativeEndian.PutUint16(buf[4:], unix.NLMSG_DONE)
+	binary.NativeEndian.PutUint32(buf[12:], uint32(os.Getpid()))
+	// struct cn_msg.
+	msg := buf[unix.SizeofNlMsghdr:]
+	binary.NativeEndian.PutUint32(msg[0:], cnIdxProc)
+	binary.NativeEndian.PutUint32(msg[4:], cnValProc)
+	binary.NativeEndian.PutUint16(msg[16:], 4)
+	// enum proc_cn_mcast_op.
+	binary.NativeEndian.PutUint32(msg[sizeofCnMsg:], op)
+
+	return errors.Wrap(unix.Sendto(fd, buf, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}), "Send operation")
+}
+
+// readProcEvents reads netlink messages from `file` and delivers parsed events to `listener` until it is closed.
+func readProcEvents(file *os.File, listener *ProcEventListener) {
+	defer close(listener.events)
+
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, err := file.Read(buf)
+		if errors.Is(err, unix.ENOBUFS) {
+			// The socket receive buffer overflowed, some events are lost.
+			if !listener.deliver(ProcEvent{Kind: ProcEventOverflow}) {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			event, ok := parseProcEvent(msg.Data)
+			if !ok {
+				continue
+			}
+			if !listener.deliver(event) {
+				return
+			}
+		}
+	}
+}
+
+// deliver sends `event` to the events channel. Returns false if the listener is closed.
+func (l *ProcEventListener) deliver(event ProcEvent) bool {
+	select {
+	case l.events <- event:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+// parseProcEvent parses struct cn_msg with struct proc_event payload from `data`.
+//
+// Returns false if `data` does not contain a supported event.
+func parseProcEvent(data []byte) (ProcEvent, bool) {
+	if len(data) < sizeofCnMsg+procEventDataOffset {
+		return ProcEvent{}, false
+	}
+	if binary.NativeEndian.Uint32(data[0:]) != cnIdxProc || binary.NativeEndian.Uint32(data[4:]) != cnValProc {
+		return ProcEvent{}, false
+	}
+	what := binary.NativeEndian.Uint32(data[sizeofCnMsg:])
+	payload := data[sizeofCnMsg+procEventDataOffset:]
+	field := func(i int) int {
+		if len(payload) < (i+1)*4 {
+			return 0
+		}
+		return int(int32(binary.NativeEndian.Uint32(payload[i*4:])))
+	}
+
+	switch what {
+	case procEventFork:
+		// parent_pid, parent_tgid, child_pid, child_tgid.
+		return ProcEvent{Kind: ProcEventFork, ParentPID: field(1), TID: field(2), PID: field(3)}, true
+	case procEventExec:
+		// process_pid, process_tgid.
+		return ProcEvent{Kind: ProcEventExec, TID: field(0), PID: field(1)}, true
+	case procEventUID:
+		// process_pid, process_tgid, ruid, euid.
+		return ProcEvent{Kind: ProcEventUID, TID: field(0), PID: field(1), UID: field(2), EUID: field(3)}, true
+	case procEventExit:
+		// process_pid, process_tgid, exit_code, exit_signal, parent_pid, parent_tgid.
+		return ProcEvent{
+			Kind:       ProcEventExit,
+			TID:        field(0),
+			PID:        field(1),
+			ExitCode:   field(2),
+			ExitSignal: field(3),
+			ParentPID:  field(5),
+		}, true
+	default:
+		return ProcEvent{}, false
+	}
+}
+
+// procExitNotify returns a channel receiving a value each time a thread of the process with PID `pid` exits or events
+// are lost, until `ctx` is done.
+//
+// Returns nil channel if the process event stream is not available.
+func procExitNotify(ctx context.Context, pid int) <-chan struct{} {
+	listener, err := shareProcEvents(ctx)
+	if err != nil {
+		return nil
+	}
+	out := make(chan struct{}, 1)
+	go func() {
+		for event := range listener.Events() {
+			if (event.Kind == ProcEventExit && event.PID == pid) || event.Kind == ProcEventOverflow {
+				select {
+				case out <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// procEventHub distributes the events of a single proc connector subscription to the listeners returned by
+// shareProcEvents.
+type procEventHub struct {
+	listener *ProcEventListener
+	// subs maps the listeners to whether they missed events because their buffer was full.
+	subs map[*ProcEventListener]bool
+}
+
+var (
+	sharedProcEventsMu sync.Mutex
+	sharedProcEvents   *procEventHub
+)
+
+// shareProcEvents returns a listener of the proc connector subscription shared by the internal users of the process
+// events, which is created on demand and closed with the last listener.
+//
+// Unlike ListenProcEvents, events are not waited to be received: ProcEventOverflow is delivered instead of the events
+// which did not fit the buffer of the listener.
+func shareProcEvents(ctx context.Context) (*ProcEventListener, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "Listen process events")
+	default:
+	}
+
+	sharedProcEventsMu.Lock()
+	defer sharedProcEventsMu.Unlock()
+	if sharedProcEvents == nil {
+		listener, err := ListenProcEvents(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		sharedProcEvents = &procEventHub{listener: listener, subs: map[*ProcEventListener]bool{}}
+		go sharedProcEvents.run()
+	}
+	hub := sharedProcEvents
+	sub := &ProcEventListener{events: make(chan ProcEvent, 256), done: make(chan struct{})}
+	sub.closeFn = func() error {
+		hub.remove(sub)
+		return nil
+	}
+	hub.subs[sub] = false
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = sub.Close()
+		case <-sub.done:
+		}
+	}()
+	return sub, nil
+}
+
+// run distributes the events to the listeners until the subscription ends.
+func (h *procEventHub) run() {
+	for event := range h.listener.Events() {
+		sharedProcEventsMu.Lock()
+		for sub, lost := range h.subs {
+			if lost {
+				select {
+				case sub.events <- ProcEvent{Kind: ProcEventOverflow}:
+				default:
+					continue
+				}
+			}
+			select {
+			case sub.events <- event:
+				h.subs[sub] = false
+			default:
+				h.subs[sub] = true
+			}
+		}
+		sharedProcEventsMu.Unlock()
+	}
+
+	sharedProcEventsMu.Lock()
+	defer sharedProcEventsMu.Unlock()
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+	if sharedProcEvents == h {
+		sharedProcEvents = nil
+	}
+}
+
+// remove unsubscribes the listener `sub` and closes the subscription if it was the last one.
+func (h *procEventHub) remove(sub *ProcEventListener) {
+	sharedProcEventsMu.Lock()
+	defer sharedProcEventsMu.Unlock()
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+	if len(h.subs) == 0 && sharedProcEvents == h {
+		sharedProcEvents = nil
+		_ = h.listener.Close()
+	}
+}
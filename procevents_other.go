@@ -0,0 +1,24 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ListenProcEvents always returns ErrProcEventsUnavailable as the process event stream is only implemented on Linux.
+func ListenProcEvents(ctx context.Context) (*ProcEventListener, error) {
+	return nil, errors.Wrap(ErrProcEventsUnavailable, "Listen process events")
+}
+
+// procExitNotify returns nil channel as the process event stream is not available.
+func procExitNotify(ctx context.Context, pid int) <-chan struct{} {
+	return nil
+}
+
+// shareProcEvents always returns ErrProcEventsUnavailable as the process event stream is only implemented on Linux.
+func shareProcEvents(ctx context.Context) (*ProcEventListener, error) {
+	return nil, errors.Wrap(ErrProcEventsUnavailable, "Listen process events")
+}
@@ -0,0 +1,118 @@
+package terminator
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// StopProfile is a named stop policy following graceful stop conventions of a particular program.
+type StopProfile struct {
+	Name string
+	// Executables is a list of executable names (without extension) the profile applies to, case-insensitive.
+	Executables []string
+	// Match is an optional function reporting whether the profile applies to a process with executable path `exe` and
+	// command line `cmdline`. Checked if none of Executables matched.
+	Match  func(exe string, cmdline []string) bool
+	Policy Policy
+}
+
+var (
+	stopProfilesMu sync.RWMutex
+	stopProfiles   = builtinStopProfiles()
+)
+
+// RegisterStopProfile adds `profile` to the registry, replacing the profile with the same name if any.
+//
+// Profiles registered later take precedence during detection.
+func RegisterStopProfile(profile StopProfile) {
+	stopProfilesMu.Lock()
+	defer stopProfilesMu.Unlock()
+	stopProfiles = slices.DeleteFunc(stopProfiles, func(p StopProfile) bool {
+		return p.Name == profile.Name
+	})
+	stopProfiles = append(stopProfiles, profile)
+}
+
+// LookupStopProfile returns registered profile with name `name` and true, or false if there is no such profile.
+func LookupStopProfile(name string) (StopProfile, bool) {
+	stopProfilesMu.RLock()
+	defer stopProfilesMu.RUnlock()
+	idx := slices.IndexFunc(stopProfiles, func(p StopProfile) bool {
+		return p.Name == name
+	})
+	if idx < 0 {
+		return StopProfile{}, false
+	}
+	return stopProfiles[idx], true
+}
+
+// StopProfiles returns all registered profiles in registration order.
+func StopProfiles() []StopProfile {
+	stopProfilesMu.RLock()
+	defer stopProfilesMu.RUnlock()
+	return slices.Clone(stopProfiles)
+}
+
+// DetectStopProfile returns the registered profile matching executable or command line of the process with PID `pid`
+// and true, or false if none of the profiles match.
+func DetectStopProfile(pid int) (StopProfile, bool, error) {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return StopProfile{}, false, errors.Wrapf(err, "Detect stop profile of the process with PID %v", pid)
+	}
+	exe, _ := proc.Exe()
+	cmdline, _ := proc.CmdlineSlice()
+	name, _ := proc.Name()
+
+	names := []string{exeName(exe), exeName(name)}
+	if len(cmdline) > 0 {
+		names = append(names, exeName(cmdline[0]))
+	}
+
+	stopProfilesMu.RLock()
+	defer stopProfilesMu.RUnlock()
+	for _, profile := range slices.Backward(stopProfiles) {
+		if slices.ContainsFunc(profile.Executables, func(executable string) bool {
+			return slices.Contains(names, strings.ToLower(executable))
+		}) {
+			return profile, true, nil
+		}
+		if profile.Match != nil && profile.Match(exe, cmdline) {
+			return profile, true, nil
+		}
+	}
+	return StopProfile{}, false, nil
+}
+
+// PolicyFor returns policy of the profile detected for the process with PID `pid` (see DetectStopProfile) or
+// DefaultPolicy if none of the profiles match.
+func PolicyFor(pid int) Policy {
+	if profile, ok, _ := DetectStopProfile(pid); ok {
+		return profile.Policy
+	}
+	return DefaultPolicy()
+}
+
+// resolve returns policy `p` with the stages of the profile detected for the process with PID `pid` if AutoProfile is
+// set.
+func (p Policy) resolve(pid int) Policy {
+	if !p.AutoProfile {
+		return p
+	}
+	if profile, ok, _ := DetectStopProfile(pid); ok {
+		p.Stages = profile.Policy.Stages
+	}
+	p.AutoProfile = false
+	return p
+}
+
+// exeName returns lower case base name of the executable path `path` without extension.
+func exeName(path string) string {
+	base := strings.ToLower(filepath.Base(path))
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
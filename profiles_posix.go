@@ -0,0 +1,46 @@
+//go:build !windows
+
+package terminator
+
+import (
+	"syscall"
+	"time"
+)
+
+// builtinStopProfiles returns stop profiles of well-known daemons.
+func builtinStopProfiles() []StopProfile {
+	return []StopProfile{
+		{
+			// SIGQUIT for graceful shutdown (finish serving current requests), SIGTERM for fast shutdown.
+			// See https://nginx.org/en/docs/control.html.
+			Name:        "nginx",
+			Executables: []string{"nginx"},
+			Policy: Policy{Stages: []Stage{
+				{Action: ActionSignal, Signal: syscall.SIGQUIT, Grace: time.Second * 10},
+				{Action: ActionSignal, Signal: syscall.SIGTERM, Grace: time.Second * 5},
+				{Action: ActionKill, Grace: time.Second * 5},
+			}},
+		},
+		{
+			// SIGTERM for smart, SIGINT for fast and SIGQUIT for immediate shutdown.
+			// See https://www.postgresql.org/docs/current/server-shutdown.html.
+			Name:        "postgresql",
+			Executables: []string{"postgres", "postmaster"},
+			Policy: Policy{Stages: []Stage{
+				{Action: ActionSignal, Signal: syscall.SIGTERM, Grace: time.Second * 30},
+				{Action: ActionSignal, Signal: syscall.SIGINT, Grace: time.Second * 10},
+				{Action: ActionSignal, Signal: syscall.SIGQUIT, Grace: time.Second * 5},
+				{Action: ActionKill, Grace: time.Second * 5},
+			}},
+		},
+		{
+			// The JVM runs shutdown hooks on SIGTERM, SIGINT is not handled by many applications.
+			Name:        "java",
+			Executables: []string{"java"},
+			Policy: Policy{Stages: []Stage{
+				{Action: ActionSignal, Signal: syscall.SIGTERM, Grace: time.Second * 10},
+				{Action: ActionKill, Grace: time.Second * 5},
+			}},
+		},
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package terminator
+
+// builtinStopProfiles returns no profiles as the built-in profiles rely on POSIX signals.
+func builtinStopProfiles() []StopProfile {
+	return []StopProfile{}
+}
@@ -0,0 +1,85 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Selector resolves PID's of the processes an operation targets.
+type Selector interface {
+	// Select returns PID's of the matching processes using context `ctx`.
+	Select(ctx context.Context) ([]int, error)
+	// String returns human readable description of the selector.
+	String() string
+}
+
+// PIDSelector selects processes with the specified PID's.
+type PIDSelector []int
+
+// Select is used to implement Selector interface.
+func (s PIDSelector) Select(ctx context.Context) ([]int, error) {
+	return slices.Clone(s), nil
+}
+
+// String is used to implement Selector interface.
+func (s PIDSelector) String() string {
+	return fmt.Sprintf("pid %v", []int(s))
+}
+
+// StopSelector is the same as StopSelectorWithContext with background context.
+func StopSelector(sel Selector, policy Policy) ([]StopResult, error) {
+	return StopSelectorWithContext(context.Background(), sel, policy)
+}
+
+// StopSelectorWithContext stops all processes matched by selector `sel` with `policy` using context `ctx`,
+// concurrently.
+//
+// Returns results of every matched process in the order returned by the selector and combined errors of failed stops.
+//
+// PidfileSelector is stopped with StopPidfileWithContext, holding the lock of the pidfile.
+func StopSelectorWithContext(ctx context.Context, sel Selector, policy Policy) ([]StopResult, error) {
+	if pidfile, ok := sel.(PidfileSelector); ok {
+		result, err := StopPidfileWithContext(ctx, pidfile.Path, pidfile.Options, policy)
+		if result.PID == 0 || errors.HasType(err, ErrStalePidfile{}) {
+			// The process was not resolved.
+			return nil, err
+		}
+		return []StopResult{result}, err
+	}
+	pids, err := sel.Select(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Stop processes matching %v", sel)
+	}
+	emit(ctx, ObserverEvent{Kind: EventTargetResolved, PIDs: pids, Target: sel.String()})
+	results, err := stopPids(ctx, pids, policy)
+	return results, errors.Wrapf(err, "Stop processes matching %v", sel)
+}
+
+// stopPids stops processes `pids` with `policy` using context `ctx`, concurrently.
+//
+// Returns results in the order of `pids` and combined errors of failed stops.
+func stopPids(ctx context.Context, pids []int, policy Policy) ([]StopResult, error) {
+	ctx, err := checkGuard(ctx, "stop", pids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]StopResult, len(pids))
+	errs := make([]error, len(pids))
+	var wg sync.WaitGroup
+	for i, pid := range pids {
+		wg.Go(func() {
+			results[i], errs[i] = StopWithContext(ctx, pid, policy)
+		})
+	}
+	wg.Wait()
+
+	var result error
+	for _, err := range errs {
+		result = errors.CombineErrors(result, err)
+	}
+	return results, result
+}
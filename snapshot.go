@@ -0,0 +1,118 @@
+package terminator
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ProcSnapshot is an index of parent and child relations of all processes, taken at once.
+//
+// Use it instead of multiple FlatChildTree calls to avoid rescanning the processes for every lookup.
+type ProcSnapshot struct {
+	parents  map[int]int
+	children map[int][]int
+}
+
+// NewProcSnapshot takes a snapshot of parent and child relations of all running processes.
+func NewProcSnapshot() (*ProcSnapshot, error) {
+	parents, err := readProcParents()
+	if err != nil {
+		return nil, errors.Wrap(err, "Take process snapshot")
+	}
+	return newProcSnapshot(parents), nil
+}
+
+// newProcSnapshot returns new ProcSnapshot built from the PID to parent PID mapping `parents`.
+func newProcSnapshot(parents map[int]int) *ProcSnapshot {
+	children := map[int][]int{}
+	for pid, ppid := range parents {
+		if pid != ppid {
+			children[ppid] = append(children[ppid], pid)
+		}
+	}
+	for _, pids := range children {
+		slices.Sort(pids)
+	}
+	return &ProcSnapshot{parents: parents, children: children}
+}
+
+// Pids returns PID's of all processes in the snapshot in ascending order.
+func (s *ProcSnapshot) Pids() []int {
+	return slices.Sorted(maps.Keys(s.parents))
+}
+
+// Exists returns true if the process with PID `pid` is in the snapshot.
+func (s *ProcSnapshot) Exists(pid int) bool {
+	_, ok := s.parents[pid]
+	return ok
+}
+
+// Parent returns parent PID of the process with PID `pid` and true if the process is in the snapshot.
+func (s *ProcSnapshot) Parent(pid int) (int, bool) {
+	ppid, ok := s.parents[pid]
+	return ppid, ok
+}
+
+// Children returns PID's of direct children of the process with PID `pid` in ascending order.
+func (s *ProcSnapshot) Children(pid int) []int {
+	return slices.Clone(s.children[pid])
+}
+
+// FlatChildTree returns PID's of all descendants of a process with the specified PID `pid`.
+//
+// The first element is deepest descendant. The last one is a progenitor or closest child.
+//
+// If the `withRoot` argument is set to true, add root process to the end.
+//
+// Each process is returned once even if parent relations form a cycle (possible because of PID reuse).
+func (s *ProcSnapshot) FlatChildTree(pid int, withRoot bool) []int {
+	type frame struct {
+		pid      int
+		children []int
+		// Index of the next child to visit. Children are visited in reverse order.
+		next int
+	}
+
+	tree := []int{}
+	visited := map[int]bool{pid: true}
+	stack := []frame{{pid: pid, children: s.children[pid], next: len(s.children[pid]) - 1}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next < 0 {
+			// All descendants are collected, add the process itself after them.
+			done := top.pid
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 || withRoot {
+				tree = append(tree, done)
+			}
+			continue
+		}
+		child := top.children[top.next]
+		top.next--
+		if visited[child] {
+			continue
+		}
+		visited[child] = true
+		stack = append(stack, frame{pid: child, children: s.children[child], next: len(s.children[child]) - 1})
+	}
+	return tree
+}
+
+// Ancestors returns PID's of all ancestors of a process with the specified PID `pid`, closest parent first.
+//
+// Each process is returned once even if parent relations form a cycle (possible because of PID reuse).
+func (s *ProcSnapshot) Ancestors(pid int) []int {
+	ancestors := []int{}
+	visited := map[int]bool{pid: true}
+	for {
+		ppid, ok := s.parents[pid]
+		if !ok || ppid <= 0 || visited[ppid] {
+			return ancestors
+		}
+		visited[ppid] = true
+		ancestors = append(ancestors, ppid)
+		pid = ppid
+	}
+}
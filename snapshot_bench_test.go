@@ -0,0 +1,69 @@
+//go:build !windows
+
+package terminator
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// startBenchTree starts a tree of 13 processes and returns PID of it's root, which is killed after the benchmark.
+func startBenchTree(b *testing.B) int {
+	b.Helper()
+	cmd := exec.Command("sh", "-c", `for i in 1 2 3 4; do sh -c "sleep 60 & sleep 60 & wait" & done; wait`)
+	if err := cmd.Start(); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		// The tree is above GuardOptions.BulkThreshold.
+		if err := KillTreeWithContext(WithGuardOverride(context.Background()), cmd.Process.Pid, true); err != nil {
+			b.Error(err)
+		}
+		_ = cmd.Wait()
+	})
+	// Let the children start.
+	time.Sleep(time.Millisecond * 300)
+	return cmd.Process.Pid
+}
+
+// flatChildTreePerNode returns descendants of the process with PID `pid` calling Children() for every node, as
+// FlatChildTree did before ProcSnapshot.
+func flatChildTreePerNode(pid int) ([]*process.Process, error) {
+	root, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return nil, err
+	}
+	tree := []*process.Process{}
+	var walk func(proc *process.Process)
+	walk = func(proc *process.Process) {
+		children, _ := proc.Children()
+		for _, child := range children {
+			walk(child)
+			tree = append(tree, child)
+		}
+	}
+	walk(root)
+	return tree, nil
+}
+
+func BenchmarkFlatChildTreeSnapshot(b *testing.B) {
+	pid := startBenchTree(b)
+	for b.Loop() {
+		if _, err := FlatChildTree(pid, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFlatChildTreePerNode(b *testing.B) {
+	pid := startBenchTree(b)
+	for b.Loop() {
+		if _, err := flatChildTreePerNode(pid); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
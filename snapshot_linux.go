@@ -0,0 +1,65 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+)
+
+// procPath returns path to the file `elem` in the proc filesystem.
+func procPath(elem ...string) string {
+	return filepath.Join(append([]string{"/proc"}, elem...)...)
+}
+
+// readProcParents returns mapping between PID's of all running processes and their parent PID's, reading the proc
+// filesystem once.
+func readProcParents() (map[int]int, error) {
+	entries, err := os.ReadDir(procPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "Read process parents")
+	}
+	parents := make(map[int]int, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(procPath(entry.Name(), "stat"))
+		if err != nil {
+			// The process exited during the scan.
+			continue
+		}
+		fields, ok := parseProcStat(stat)
+		if !ok {
+			continue
+		}
+		ppid, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			continue
+		}
+		parents[pid] = ppid
+	}
+	return parents, nil
+}
+
+// parseProcStat returns fields of /proc/<pid>/stat file content `stat` following the command name, starting from the
+// state field (field 3 in proc(5)).
+//
+// Returns false if `stat` is malformed.
+func parseProcStat(stat []byte) ([][]byte, bool) {
+	// The command name is in parentheses and can contain spaces and parentheses itself.
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, false
+	}
+	fields := bytes.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return nil, false
+	}
+	return fields, true
+}
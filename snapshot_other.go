@@ -0,0 +1,27 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// readProcParents returns mapping between PID's of all running processes and their parent PID's, enumerating the
+// processes once.
+func readProcParents() (map[int]int, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, errors.Wrap(err, "Read process parents")
+	}
+	parents := make(map[int]int, len(procs))
+	for _, proc := range procs {
+		ppid, err := proc.Ppid()
+		if err != nil {
+			// The process exited during the scan.
+			continue
+		}
+		parents[int(proc.Pid)] = int(ppid)
+	}
+	return parents, nil
+}
@@ -0,0 +1,74 @@
+package terminator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProcSnapshotCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		parents   map[int]int
+		pid       int
+		tree      []int
+		ancestors []int
+	}{
+		{
+			name:      "tree",
+			parents:   map[int]int{1: 0, 2: 1, 3: 1, 4: 2, 5: 2},
+			pid:       1,
+			tree:      []int{3, 5, 4, 2, 1},
+			ancestors: []int{},
+		},
+		{
+			name:      "subtree",
+			parents:   map[int]int{1: 0, 2: 1, 3: 1, 4: 2, 5: 2},
+			pid:       4,
+			tree:      []int{4},
+			ancestors: []int{2, 1},
+		},
+		{
+			name:      "cycle root",
+			parents:   map[int]int{10: 11, 11: 10, 12: 11},
+			pid:       10,
+			tree:      []int{12, 11, 10},
+			ancestors: []int{11},
+		},
+		{
+			name:      "cycle leaf",
+			parents:   map[int]int{10: 11, 11: 10, 12: 11},
+			pid:       12,
+			tree:      []int{12},
+			ancestors: []int{11, 10},
+		},
+		{
+			name:      "self parent",
+			parents:   map[int]int{20: 20, 21: 20},
+			pid:       20,
+			tree:      []int{21, 20},
+			ancestors: []int{},
+		},
+		{
+			name:      "child of self parent",
+			parents:   map[int]int{20: 20, 21: 20},
+			pid:       21,
+			tree:      []int{21},
+			ancestors: []int{20},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snap := newProcSnapshot(test.parents)
+			if tree := snap.FlatChildTree(test.pid, true); !slices.Equal(tree, test.tree) {
+				t.Errorf("FlatChildTree(%v, true) = %v, want %v", test.pid, tree, test.tree)
+			}
+			withoutRoot := test.tree[:len(test.tree)-1]
+			if tree := snap.FlatChildTree(test.pid, false); !slices.Equal(tree, withoutRoot) {
+				t.Errorf("FlatChildTree(%v, false) = %v, want %v", test.pid, tree, withoutRoot)
+			}
+			if ancestors := snap.Ancestors(test.pid); !slices.Equal(ancestors, test.ancestors) {
+				t.Errorf("Ancestors(%v) = %v, want %v", test.pid, ancestors, test.ancestors)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package terminator
+
+import (
+	"fmt"
+)
+
+// ProcState is a scheduler state of a process or thread.
+type ProcState byte
+
+const (
+	// StateRunning indicates that the process is running or runnable.
+	StateRunning ProcState = 'R'
+	// StateSleeping indicates interruptible sleep.
+	StateSleeping ProcState = 'S'
+	// StateDiskSleep indicates uninterruptible sleep, usually waiting for I/O. Signals are not delivered until it ends.
+	StateDiskSleep ProcState = 'D'
+	// StateStopped indicates that the process is stopped by job control signal. Signals other than SIGKILL and SIGCONT
+	// stay pending until it is continued.
+	StateStopped ProcState = 'T'
+	// StateTracingStop indicates that the process is stopped by a debugger.
+	StateTracingStop ProcState = 't'
+	// StateZombie indicates that the process exited but is not reaped by it's parent yet.
+	StateZombie ProcState = 'Z'
+	// StateDead indicates that the process is being destroyed.
+	StateDead ProcState = 'X'
+	// StateIdle indicates an idle kernel thread.
+	StateIdle ProcState = 'I'
+)
+
+// String is used to implement fmt.Stringer interface.
+func (s ProcState) String() string {
+	switch s {
+	case StateRunning:
+		return "running"
+	case StateSleeping:
+		return "sleeping"
+	case StateDiskSleep:
+		return "uninterruptible sleep"
+	case StateStopped:
+		return "stopped"
+	case StateTracingStop:
+		return "tracing stop"
+	case StateZombie:
+		return "zombie"
+	case StateDead:
+		return "dead"
+	case StateIdle:
+		return "idle"
+	default:
+		return fmt.Sprintf("unknown (%c)", s)
+	}
+}
+
+// ThreadState is a state of a single thread of a process.
+type ThreadState struct {
+	TID   int
+	State ProcState
+	// WChan is a kernel function the thread is waiting in, if known.
+	WChan string
+}
+
+// ProcStateInfo is a state of a process and it's threads.
+type ProcStateInfo struct {
+	PID   int
+	State ProcState
+	// WChan is a kernel function the main thread is waiting in, if known.
+	WChan string
+	// TracerPID is a PID of the process tracing this one (e.g. debugger) or 0 if it is not traced.
+	TracerPID int
+	Threads   []ThreadState
+}
+
+// Uninterruptible returns true if the process or any of it's threads is in uninterruptible sleep.
+func (i ProcStateInfo) Uninterruptible() bool {
+	if i.State == StateDiskSleep {
+		return true
+	}
+	for _, thread := range i.Threads {
+		if thread.State == StateDiskSleep {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrUninterruptible indicates that the process can not be killed right now as it is in uninterruptible sleep, usually
+// waiting for I/O. Pending signals are delivered after the sleep ends.
+type ErrUninterruptible struct {
+	PID   int
+	WChan string
+}
+
+// Error is used to implement error interface.
+func (e ErrUninterruptible) Error() string {
+	if e.WChan == "" {
+		return fmt.Sprintf("The process with PID %v cannot be killed right now: uninterruptible I/O", e.PID)
+	}
+	return fmt.Sprintf("The process with PID %v cannot be killed right now: uninterruptible I/O in %v", e.PID, e.WChan)
+}
+
+// newErrUninterruptible returns new ErrUninterruptible with PID `pid` and kernel wait channel `wchan`.
+func newErrUninterruptible(pid int, wchan string) ErrUninterruptible {
+	return ErrUninterruptible{PID: pid, WChan: wchan}
+}
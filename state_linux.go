@@ -0,0 +1,149 @@
+//go:build linux
+
+package terminator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// GetProcState returns scheduler state, kernel wait channel, tracer PID and thread states of the process with PID `pid`.
+func GetProcState(pid int) (ProcStateInfo, error) {
+	info := ProcStateInfo{PID: pid}
+	dir := strconv.Itoa(pid)
+
+	state, err := readProcState(procPath(dir, "stat"))
+	if err != nil {
+		return info, errors.Wrapf(err, "Get state of the process with PID %v", pid)
+	}
+	info.State = state
+	info.WChan = readWChan(procPath(dir, "wchan"))
+	info.TracerPID, err = readTracerPid(pid)
+	if err != nil {
+		return info, errors.Wrapf(err, "Get state of the process with PID %v", pid)
+	}
+
+	entries, err := os.ReadDir(procPath(dir, "task"))
+	if err != nil {
+		return info, errors.Wrapf(err, "Get state of the process with PID %v: List threads", pid)
+	}
+	for _, entry := range entries {
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		state, err := readProcState(procPath(dir, "task", entry.Name(), "stat"))
+		if err != nil {
+			// The thread exited during the scan.
+			continue
+		}
+		info.Threads = append(info.Threads, ThreadState{
+			TID:   tid,
+			State: state,
+			WChan: readWChan(procPath(dir, "task", entry.Name(), "wchan")),
+		})
+	}
+	return info, nil
+}
+
+// readProcState returns state field of the stat file with path `path`.
+func readProcState(path string) (ProcState, error) {
+	stat, err := os.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "Read process state")
+	}
+	fields, ok := parseProcStat(stat)
+	if !ok || len(fields[0]) != 1 {
+		return 0, errors.Newf("Read process state: Malformed %v", path)
+	}
+	return ProcState(fields[0][0]), nil
+}
+
+// readWChan returns content of the wchan file with path `path` or empty string if it is not available.
+func readWChan(path string) string {
+	wchan, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	// "0" is reported for running threads or if the address is hidden.
+	if value := strings.TrimSpace(string(wchan)); value != "0" {
+		return value
+	}
+	return ""
+}
+
+// readTracerPid returns TracerPid field of /proc/<pid>/status file of the process with PID `pid`.
+func readTracerPid(pid int) (int, error) {
+	status, err := os.ReadFile(procPath(strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, errors.Wrap(err, "Read tracer PID")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		value, ok := bytes.CutPrefix(scanner.Bytes(), []byte("TracerPid:"))
+		if !ok {
+			continue
+		}
+		tracer, err := strconv.Atoi(string(bytes.TrimSpace(value)))
+		return tracer, errors.Wrap(err, "Read tracer PID")
+	}
+	return 0, errors.New("Read tracer PID: Field not found")
+}
+
+// isZombie returns true if the process with PID `pid` is a zombie or is being destroyed.
+func isZombie(pid int) bool {
+	state, err := readProcState(procPath(strconv.Itoa(pid), "stat"))
+	return err == nil && (state == StateZombie || state == StateDead)
+}
+
+// pfKthread is a flag of kernel threads in the flags field of /proc/[pid]/stat. See linux/sched.h.
+const pfKthread = 0x00200000
+
+// isKernelThread returns true if the process with PID `pid` is a kernel thread.
+func isKernelThread(pid int) bool {
+	if pid == 2 {
+		// kthreadd, the parent of all kernel threads.
+		return true
+	}
+	stat, err := os.ReadFile(procPath(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	fields, ok := parseProcStat(stat)
+	if !ok || len(fields) < 7 {
+		return false
+	}
+	flags, err := strconv.ParseUint(string(fields[6]), 10, 64)
+	return err == nil && flags&pfKthread != 0
+}
+
+// continueIfStopped sends SIGCONT to the process with PID `pid` using context `ctx` if it is stopped by job control, so
+// it can react to the signals sent next.
+func continueIfStopped(ctx context.Context, pid int) error {
+	state, err := readProcState(procPath(strconv.Itoa(pid), "stat"))
+	if err != nil || state != StateStopped {
+		return nil
+	}
+	return errors.Wrapf(SendSignalWithContext(ctx, pid, unix.SIGCONT), "Continue stopped process with PID %v", pid)
+}
+
+// isSuspended returns true if the process with PID `pid` is stopped by a signal or a debugger.
+//
+// Returns error if the process does not exist or is a zombie.
+func isSuspended(pid int) (bool, error) {
+	state, err := readProcState(procPath(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false, err
+	}
+	if state == StateZombie || state == StateDead {
+		return false, errors.Newf("Get suspended state of the process with PID %v: Process exited", pid)
+	}
+	return state == StateStopped || state == StateTracingStop, nil
+}
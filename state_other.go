@@ -0,0 +1,49 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/cockroachdb/errors"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// GetProcState is only implemented on Linux, returns ErrNotSupported.
+func GetProcState(pid int) (ProcStateInfo, error) {
+	return ProcStateInfo{PID: pid}, errors.Wrapf(ErrNotSupported, "Get state of the process with PID %v", pid)
+}
+
+// isZombie returns false as the process state is not inspected on this platform.
+func isZombie(pid int) bool {
+	return false
+}
+
+// isKernelThread returns false as kernel threads are not visible as processes on this platform.
+func isKernelThread(pid int) bool {
+	return false
+}
+
+// continueIfStopped does nothing as the process state is not inspected on this platform.
+func continueIfStopped(_ context.Context, pid int) error {
+	return nil
+}
+
+// isSuspended returns true if the process with PID `pid` is stopped.
+//
+// Returns error if the process does not exist, is a zombie or the state is not available on this platform.
+func isSuspended(pid int) (bool, error) {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return false, errors.Wrapf(err, "Get suspended state of the process with PID %v", pid)
+	}
+	status, err := proc.Status()
+	if err != nil {
+		return false, errors.Wrapf(err, "Get suspended state of the process with PID %v", pid)
+	}
+	if slices.Contains(status, process.Zombie) {
+		return false, errors.Newf("Get suspended state of the process with PID %v: Process exited", pid)
+	}
+	return slices.Contains(status, process.Stop), nil
+}
@@ -0,0 +1,366 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// StageAction is an action performed by a stage of a graceful stop.
+type StageAction string
+
+const (
+	// ActionSignal sends Stage.Signal to the process.
+	ActionSignal StageAction = "signal"
+	// ActionAutoSignal sends the first signal of Stage.Signals (or DefaultAutoSignals if empty) which the process
+	// catches or leaves at a terminating default action, skipping ignored ones. See SignalDisposition.
+	//
+	// If none of the signals is suitable, the stage is skipped without waiting for it's grace period.
+	ActionAutoSignal StageAction = "auto"
+	// ActionMessage writes Stage.Message to the console of the process, see SendMessage.
+	ActionMessage StageAction = "message"
+	// ActionKill kills the process.
+	ActionKill StageAction = "kill"
+	// ActionCommand runs Stage.Command, e.g. "app stop", with Stage.Timeout. "{pid}" in the arguments is replaced with
+	// the PID of the process.
+	ActionCommand StageAction = "command"
+	// ActionHTTP sends a Stage.Method request to the Stage.URL, e.g. POST to http://127.0.0.1:8080/shutdown, with
+	// Stage.Timeout. Only loopback hosts are allowed.
+	ActionHTTP StageAction = "http"
+	// ActionDrain writes Stage.Message to the console of the process if set, or sends Stage.Signal if set, and waits
+	// until the process has no listening TCP sockets and no active TCP connections left, or the grace period passes.
+	// The policy escalates to the next stage after that if the process is still running. See Policy.OnDrainProgress.
+	ActionDrain StageAction = "drain"
+	// ActionDiagnostics captures a diagnostics bundle of the process using Stage.Diagnostics options, see
+	// CaptureDiagnostics. Meant to be placed before the kill stages to keep the evidence of why the process hung.
+	ActionDiagnostics StageAction = "diagnostics"
+)
+
+// GraceMode defines how the grace period of a stage is computed.
+type GraceMode string
+
+const (
+	// GraceAbsolute uses Stage.Grace as is.
+	GraceAbsolute GraceMode = ""
+	// GraceProportional uses Stage.Share of the time left until the deadline of the stop context at the start of the
+	// stop, e.g. 0.7 for 70%.
+	GraceProportional GraceMode = "proportional"
+	// GraceRemaining uses all the time left until the deadline of the stop context.
+	GraceRemaining GraceMode = "remaining"
+)
+
+// Stage is a single step of a graceful stop.
+type Stage struct {
+	Action StageAction `json:"action"`
+	// Signal is a signal to send for ActionSignal.
+	Signal syscall.Signal `json:"signal,omitempty"`
+	// Signals is a list of candidate signals for ActionAutoSignal.
+	Signals []syscall.Signal `json:"signals,omitempty"`
+	// Message is a message to write for ActionMessage.
+	Message string `json:"message,omitempty"`
+	// Command is a program with arguments to run for ActionCommand.
+	Command []string `json:"command,omitempty"`
+	// URL is a local URL to send a request to for ActionHTTP.
+	URL string `json:"url,omitempty"`
+	// Method is a HTTP method for ActionHTTP. POST if empty.
+	Method string `json:"method,omitempty"`
+	// Body is a request body for ActionHTTP.
+	Body string `json:"body,omitempty"`
+	// Timeout limits how long the command or request of ActionCommand and ActionHTTP can take. Grace is used if 0.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Diagnostics are options for ActionDiagnostics.
+	Diagnostics *DiagnosticsOptions `json:"diagnostics,omitempty"`
+	// Grace is how long to wait for the process to exit after the action before moving to the next stage.
+	Grace time.Duration `json:"grace"`
+	// GraceMode defines how the grace period is computed if the stop context has a deadline. Stage.Grace is used if
+	// the context has no deadline.
+	GraceMode GraceMode `json:"grace_mode,omitempty"`
+	// Share is a fraction of the deadline budget for GraceProportional.
+	Share float64 `json:"share,omitempty"`
+}
+
+// Policy is an ordered list of stages to stop a process with, from the most graceful to the most forceful.
+type Policy struct {
+	Stages []Stage `json:"stages"`
+	// OnDrainProgress is called with each connections sample taken during ActionDrain stages, if not nil.
+	OnDrainProgress func(DrainProgress) `json:"-"`
+	// KillReserve is how much time before the deadline of the stop context is kept for the kill stage, if the policy
+	// has one. DefaultKillReserve is used if 0.
+	KillReserve time.Duration `json:"kill_reserve,omitempty"`
+	// AutoProfile is set to true to stop each process with the stages of the stop profile detected for it (see
+	// DetectStopProfile) instead of Stages, if any profile matches. Other fields of the policy are kept.
+	AutoProfile bool `json:"auto_profile,omitempty"`
+	// Terminal is a terminal state saved with SaveTermState to restore after the stop, if not nil. Use it for processes
+	// sharing the terminal which may leave it in raw mode, without echo or on the alternate screen when killed, such as
+	// editors and pagers. If the process was in the foreground of the terminal, the alternate screen, cursor and
+	// bracketed paste mode are reset as well (POSIX).
+	Terminal *TermState `json:"-"`
+}
+
+// DefaultKillReserve is a default value of Policy.KillReserve.
+const DefaultKillReserve = time.Second
+
+// DefaultPolicy returns a policy which sends one of DefaultAutoSignals and kills the process if it is still running
+// after 5 seconds.
+func DefaultPolicy() Policy {
+	return Policy{Stages: []Stage{
+		{Action: ActionAutoSignal, Grace: time.Second * 5},
+		{Action: ActionKill, Grace: time.Second * 5},
+	}}
+}
+
+// StageResult is an outcome of a single stage of a graceful stop.
+type StageResult struct {
+	Action StageAction
+	// Signal is the signal sent by ActionSignal or ActionAutoSignal stage.
+	Signal syscall.Signal
+	// Skipped is set to true if the stage had nothing to do, e.g. ActionAutoSignal found no suitable signal.
+	Skipped bool
+	// DiagnosticsPath is a path to the bundle written by ActionDiagnostics stage.
+	DiagnosticsPath string
+	// Grace is the grace period applied after the action.
+	Grace time.Duration
+	// Output is a combined output of the command of ActionCommand stage or response body of ActionHTTP stage.
+	Output string
+	// Drain contains connections samples taken during ActionDrain stage, in order.
+	Drain []DrainProgress
+	// Err is an error returned by the stage action, if any.
+	Err error
+}
+
+// StopResult is an outcome of a graceful stop.
+type StopResult struct {
+	PID int
+	// Stages contains results of the stages run, in order.
+	Stages []StageResult
+	// Stopped is set to true if the process is no longer running.
+	Stopped bool
+	// Exit describes how the process ended if it is stopped. See ExitInfo for the sources of the exit status.
+	Exit ExitInfo
+}
+
+// ErrNotStopped indicates that the process is still running after all stages of a policy.
+type ErrNotStopped struct {
+	PID int
+}
+
+// Error is used to implement error interface.
+func (e ErrNotStopped) Error() string {
+	return fmt.Sprintf("The process with PID %v is still running after all stop stages", e.PID)
+}
+
+// newErrNotStopped returns new ErrNotStopped with PID `pid`.
+func newErrNotStopped(pid int) ErrNotStopped {
+	return ErrNotStopped{PID: pid}
+}
+
+// Stop is the same as StopWithContext with background context.
+func Stop(pid int, policy Policy) (StopResult, error) {
+	return StopWithContext(context.Background(), pid, policy)
+}
+
+// StopWithContext stops the process with PID `pid` running stages of `policy` in order using context `ctx`, until the
+// process exits.
+//
+// Zombie processes are considered stopped. Processes stopped by job control are continued before graceful stages, so
+// they can react to the signals.
+//
+// If `ctx` has a deadline, grace periods and action timeouts are limited so the stages never run past it. Stages before
+// the first kill stage of the policy end Policy.KillReserve before the deadline, and if they run out of time the
+// remaining graceful stages are skipped and the process is killed.
+//
+// If `ctx` belongs to an operation cancelled with Operation.Cancel, the graceful stages are skipped.
+//
+// Errors of the individual stages do not interrupt the stop, they are reported in StopResult. Among others, can return
+// ErrNotStopped, ErrUninterruptible and ErrGuardViolation errors defined in this package.
+func StopWithContext(ctx context.Context, pid int, policy Policy) (result StopResult, err error) {
+	result = StopResult{PID: pid}
+	select {
+	case <-ctx.Done():
+		return result, errors.Wrapf(ctx.Err(), "Stop process with PID %v", pid)
+	default:
+	}
+
+	start := time.Now()
+	// Number and action of the last stage run.
+	lastStage, lastAction := 0, StageAction("")
+	defer func() {
+		event := ObserverEvent{PID: pid, Stage: lastStage, Action: lastAction, Elapsed: time.Since(start), Err: err}
+		event.Kind = lo.Ternary(result.Stopped, EventProcessExited, EventStopFailed)
+		emit(ctx, event)
+	}()
+
+	ctx, err = checkGuard(ctx, "stop", []int{pid})
+	if err != nil {
+		return result, errors.Wrapf(err, "Stop process with PID %v", pid)
+	}
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return result, errors.Wrapf(err, "Stop process with PID %v", pid)
+	}
+	policy = policy.resolve(pid)
+	if policy.Terminal != nil {
+		defer restoreTermAfter(policy.Terminal, pid)()
+	}
+	watcher := watchExit(ctx, pid)
+	defer func() {
+		if result.Stopped {
+			result.Exit = watcher.info()
+		}
+		watcher.close()
+	}()
+
+	budget := newStopBudget(ctx, policy)
+	stages, hasKill := policy.Stages, budget.hasKill
+	killed, timedOut := false, false
+	// forceKill appends a kill stage if the operation is cancelled and the policy has no kill stage, so the process is
+	// killed right away.
+	forceKill := func() {
+		if forceRequested(ctx) && !hasKill {
+			stages = append(slices.Clip(stages), Stage{Action: ActionKill, Grace: forceKillGrace})
+			hasKill = true
+		}
+	}
+	for i := 0; i < len(stages); i++ {
+		stage := stages[i]
+		if procStopped(proc) {
+			result.Stopped = true
+			return result, nil
+		}
+		forceKill()
+		forced := forceRequested(ctx) && stage.Action != ActionKill
+		if forced || budget.exhausted(stage) {
+			if hasKill && !killed {
+				continue
+			}
+			break
+		}
+
+		stageEvent := func(kind ObserverEventKind) ObserverEvent {
+			return ObserverEvent{Kind: kind, PID: pid, Stage: i + 1, Action: stage.Action, Elapsed: time.Since(start)}
+		}
+		if timedOut {
+			emit(ctx, stageEvent(EventEscalated))
+		}
+		emit(ctx, stageEvent(EventStageStarted))
+		lastStage, lastAction = i+1, stage.Action
+
+		stageCtx, cancelStage := budget.stageContext(withAuditStage(ctx, i+1), stage)
+		if stage.Action != ActionKill {
+			// Interrupt graceful stages if the operation is cancelled.
+			forceCtx, cancelForce := withForceCancel(stageCtx)
+			cancelBudget := cancelStage
+			stageCtx, cancelStage = forceCtx, func() {
+				cancelForce()
+				cancelBudget()
+			}
+		}
+		stageResult := runStage(stageCtx, pid, stage)
+		killed = killed || stage.Action == ActionKill
+		sent := stage.Action == ActionSignal || stage.Action == ActionAutoSignal || stageResult.Signal != 0
+		if sent && !stageResult.Skipped && stageResult.Err == nil {
+			event := stageEvent(EventSignalSent)
+			event.Signal = stageResult.Signal
+			emit(ctx, event)
+		}
+		if stageResult.Skipped {
+			cancelStage()
+			result.Stages = append(result.Stages, stageResult)
+			forceKill()
+			continue
+		}
+
+		stageResult.Grace = budget.grace(stage)
+		graceCtx, cancel := context.WithTimeout(stageCtx, stageResult.Grace)
+		var stopped bool
+		if stage.Action == ActionDrain {
+			stopped, stageResult.Drain = waitForDrain(graceCtx, pid, policy.OnDrainProgress)
+		} else {
+			stopped = waitForProcStop(graceCtx, pid)
+		}
+		cancel()
+		cancelStage()
+		result.Stages = append(result.Stages, stageResult)
+		if stopped {
+			result.Stopped = true
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return result, errors.Wrapf(ctx.Err(), "Stop process with PID %v", pid)
+		}
+		timedOut = true
+		if !forceRequested(ctx) {
+			emit(ctx, stageEvent(EventStageTimedOut))
+		}
+		// The stage may have been interrupted by Operation.Cancel, kill even if it was the last one.
+		forceKill()
+	}
+
+	if procStopped(proc) {
+		result.Stopped = true
+		return result, nil
+	}
+	if state, err := GetProcState(pid); err == nil && state.Uninterruptible() {
+		return result, newErrUninterruptible(pid, state.WChan)
+	}
+	return result, newErrNotStopped(pid)
+}
+
+// runStage performs the action of `stage` on the process with PID `pid` using context `ctx`.
+func runStage(ctx context.Context, pid int, stage Stage) StageResult {
+	result := StageResult{Action: stage.Action}
+	if stage.Action != ActionKill && stage.Action != ActionDiagnostics {
+		if err := continueIfStopped(ctx, pid); err != nil {
+			result.Err = err
+		}
+	}
+	switch stage.Action {
+	case ActionSignal:
+		result.Signal = stage.Signal
+		result.Err = errors.CombineErrors(result.Err, SendSignalWithContext(ctx, pid, stage.Signal))
+	case ActionAutoSignal:
+		candidates := stage.Signals
+		if len(candidates) == 0 {
+			candidates = DefaultAutoSignals
+		}
+		sig, ok := chooseAutoSignal(pid, candidates)
+		if !ok {
+			result.Skipped = true
+			return result
+		}
+		result.Signal = sig
+		result.Err = errors.CombineErrors(result.Err, SendSignalWithContext(ctx, pid, sig))
+	case ActionMessage:
+		result.Err = errors.CombineErrors(result.Err, SendMessageWithContext(ctx, pid, stage.Message))
+	case ActionKill:
+		result.Err = KillWithContext(ctx, pid)
+	case ActionDrain:
+		if stage.Message != "" {
+			result.Err = errors.CombineErrors(result.Err, SendMessageWithContext(ctx, pid, stage.Message))
+		} else if stage.Signal != 0 {
+			result.Signal = stage.Signal
+			result.Err = errors.CombineErrors(result.Err, SendSignalWithContext(ctx, pid, stage.Signal))
+		}
+	case ActionCommand:
+		finish := startAudit(ctx, pid, AuditRecord{Action: string(stage.Action)})
+		result.Output, result.Err = runCommandAction(ctx, pid, stage)
+		finish(&result.Err)
+	case ActionHTTP:
+		finish := startAudit(ctx, pid, AuditRecord{Action: string(stage.Action)})
+		result.Output, result.Err = runHTTPAction(ctx, stage)
+		finish(&result.Err)
+	case ActionDiagnostics:
+		finish := startAudit(ctx, pid, AuditRecord{Action: string(stage.Action)})
+		result.DiagnosticsPath, result.Err = CaptureDiagnosticsWithContext(ctx, pid, lo.FromPtr(stage.Diagnostics))
+		finish(&result.Err)
+	default:
+		result.Err = errors.Newf("Unknown stage action %q", stage.Action)
+	}
+	return result
+}
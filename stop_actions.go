@@ -0,0 +1,95 @@
+package terminator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// maxActionOutput limits the size of the command output or response body stored in StageResult.
+const maxActionOutput = 64 * 1024
+
+// runCommandAction runs the command of the ActionCommand `stage` for the process with PID `pid` using context `ctx`.
+// Returns combined output of the command.
+func runCommandAction(ctx context.Context, pid int, stage Stage) (string, error) {
+	if len(stage.Command) == 0 {
+		return "", errors.New("Run stop command: Command is empty")
+	}
+	ctx, cancel := withActionTimeout(ctx, stage)
+	defer cancel()
+
+	args := make([]string, len(stage.Command))
+	for i, arg := range stage.Command {
+		args[i] = strings.ReplaceAll(arg, "{pid}", fmt.Sprint(pid))
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	out, err := cmd.CombinedOutput()
+	if len(out) > maxActionOutput {
+		out = out[:maxActionOutput]
+	}
+	return string(out), errors.Wrapf(err, "Run stop command %q", args)
+}
+
+// runHTTPAction sends the request of the ActionHTTP `stage` using context `ctx`. Returns the response body.
+func runHTTPAction(ctx context.Context, stage Stage) (string, error) {
+	target, err := url.Parse(stage.URL)
+	if err != nil {
+		return "", errors.Wrapf(err, "Send stop request to %v", stage.URL)
+	}
+	if !isLoopbackHost(target.Hostname()) {
+		return "", errors.Newf("Send stop request to %v: Host is not a loopback address", stage.URL)
+	}
+	ctx, cancel := withActionTimeout(ctx, stage)
+	defer cancel()
+
+	method := stage.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), strings.NewReader(stage.Body))
+	if err != nil {
+		return "", errors.Wrapf(err, "Send stop request to %v", stage.URL)
+	}
+	// Do not follow redirects as they could lead to a non-local host.
+	client := http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "Send stop request to %v", stage.URL)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxActionOutput))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return string(body), errors.Newf("Send stop request to %v: Unexpected status %v", stage.URL, resp.Status)
+	}
+	return string(body), nil
+}
+
+// withActionTimeout returns a copy of `ctx` limited by the timeout of the command or request of `stage`, if any.
+func withActionTimeout(ctx context.Context, stage Stage) (context.Context, context.CancelFunc) {
+	timeout := stage.Timeout
+	if timeout == 0 {
+		timeout = stage.Grace
+	}
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// isLoopbackHost returns true if `host` is "localhost" or a loopback IP address.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
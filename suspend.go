@@ -0,0 +1,161 @@
+package terminator
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+)
+
+// suspendVerifyTimeout is how long to wait for processes to reach the requested suspended state.
+const suspendVerifyTimeout = time.Second * 5
+
+// SuspendOptions are options of Suspend family of functions.
+type SuspendOptions struct {
+	// Terminal is set to true to send SIGTSTP instead of SIGSTOP, as if Ctrl + Z was pressed. SIGTSTP can be caught or
+	// ignored by the process. Has no effect on Windows.
+	Terminal bool
+	// Cgroup is set to true to freeze the cgroup (v2) of the processes instead of sending signals, if the cgroup does not
+	// contain any other processes. Falls back to signals otherwise. Linux only.
+	Cgroup bool
+}
+
+// Suspend is the same as SuspendWithContext with background context.
+func Suspend(pid int, opts SuspendOptions) error {
+	return SuspendWithContext(context.Background(), pid, opts)
+}
+
+// SuspendWithContext pauses the process with PID `pid` using options `opts` and context `ctx` and waits until it is
+// suspended.
+func SuspendWithContext(ctx context.Context, pid int, opts SuspendOptions) error {
+	return errors.Wrapf(suspendPids(ctx, []int{pid}, opts), "Suspend process with PID %v", pid)
+}
+
+// Resume is the same as ResumeWithContext with background context.
+func Resume(pid int) error {
+	return ResumeWithContext(context.Background(), pid)
+}
+
+// ResumeWithContext continues the process with PID `pid` suspended by SuspendWithContext using context `ctx` and waits
+// until it is running.
+func ResumeWithContext(ctx context.Context, pid int) error {
+	return errors.Wrapf(resumePids(ctx, []int{pid}), "Resume process with PID %v", pid)
+}
+
+// SuspendTree is the same as SuspendTreeWithContext with background context.
+func SuspendTree(pid int, withRoot bool, opts SuspendOptions) error {
+	return SuspendTreeWithContext(context.Background(), pid, withRoot, opts)
+}
+
+// SuspendTreeWithContext pauses all descendants of the process with PID `pid` using options `opts` and context `ctx`
+// and waits until they are suspended.
+//
+// Parents are suspended before their children, so they can not react to children being stopped.
+//
+// If the `withRoot` argument is set to true, suspend the root process first.
+func SuspendTreeWithContext(ctx context.Context, pid int, withRoot bool, opts SuspendOptions) error {
+	snap, err := NewProcSnapshot()
+	if err != nil {
+		return errors.Wrapf(err, "Suspend process tree of PID %v", pid)
+	}
+	tree := snap.FlatChildTree(pid, withRoot)
+	slices.Reverse(tree)
+	return errors.Wrapf(suspendPids(ctx, tree, opts), "Suspend process tree of PID %v", pid)
+}
+
+// ResumeTree is the same as ResumeTreeWithContext with background context.
+func ResumeTree(pid int, withRoot bool) error {
+	return ResumeTreeWithContext(context.Background(), pid, withRoot)
+}
+
+// ResumeTreeWithContext continues all descendants of the process with PID `pid` suspended by SuspendTreeWithContext
+// using context `ctx` and waits until they are running.
+//
+// Children are resumed before their parents, so they are never woken up into a suspended parent.
+//
+// If the `withRoot` argument is set to true, resume the root process last.
+func ResumeTreeWithContext(ctx context.Context, pid int, withRoot bool) error {
+	snap, err := NewProcSnapshot()
+	if err != nil {
+		return errors.Wrapf(err, "Resume process tree of PID %v", pid)
+	}
+	return errors.Wrapf(resumePids(ctx, snap.FlatChildTree(pid, withRoot)), "Resume process tree of PID %v", pid)
+}
+
+// suspendPids suspends processes `pids` in order using options `opts` and context `ctx` and waits until they are
+// suspended.
+func suspendPids(ctx context.Context, pids []int, opts SuspendOptions) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	ctx, err := checkGuard(ctx, "suspend", pids)
+	if err != nil {
+		return err
+	}
+
+	if opts.Cgroup {
+		frozen, err := freezeCgroup(ctx, pids, true)
+		if err != nil {
+			return err
+		}
+		if frozen {
+			return nil
+		}
+	}
+	for _, pid := range pids {
+		if err := suspendProc(ctx, pid, opts); err != nil && !isProcGone(err) {
+			return err
+		}
+	}
+	return waitForSuspendState(ctx, pids, true)
+}
+
+// resumePids resumes processes `pids` in order using context `ctx` and waits until they are running.
+func resumePids(ctx context.Context, pids []int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	// The processes could be both frozen and stopped by a signal, so thaw the cgroup and continue them anyway.
+	if _, err := freezeCgroup(ctx, pids, false); err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := resumeProc(ctx, pid); err != nil && !isProcGone(err) {
+			return err
+		}
+	}
+	return waitForSuspendState(ctx, pids, false)
+}
+
+// waitForSuspendState returns nil when all of the processes `pids` which are still alive are in the `suspended` state,
+// or error if it takes longer than suspendVerifyTimeout or `ctx` is done.
+func waitForSuspendState(ctx context.Context, pids []int, suspended bool) error {
+	ctx, cancel := context.WithTimeout(ctx, suspendVerifyTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Millisecond * 10)
+	defer ticker.Stop()
+	for {
+		pending := lo.Filter(pids, func(pid int, _ int) bool {
+			state, err := isSuspended(pid)
+			// Exited processes or processes with unknown state are not waited for.
+			return err == nil && state != suspended
+		})
+		if len(pending) == 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "Wait for processes with PID's %v to reach suspended state %v", pending,
+				suspended)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+//go:build !windows
+
+package terminator
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"golang.org/x/sys/unix"
+)
+
+// SuspendGroup is the same as SuspendGroupWithContext with background context.
+func SuspendGroup(pgid int, opts SuspendOptions) error {
+	return SuspendGroupWithContext(context.Background(), pgid, opts)
+}
+
+// SuspendGroupWithContext pauses all processes of the process group with ID `pgid` using options `opts` and context
+// `ctx` and waits until they are suspended.
+func SuspendGroupWithContext(ctx context.Context, pgid int, opts SuspendOptions) error {
+	members, err := groupMembers(pgid)
+	if err != nil {
+		return errors.Wrapf(err, "Suspend process group %v", pgid)
+	}
+	if ctx, err = checkGuard(ctx, "suspend group", members); err != nil {
+		return errors.Wrapf(err, "Suspend process group %v", pgid)
+	}
+	if opts.Cgroup {
+		frozen, err := freezeCgroup(ctx, members, true)
+		if err != nil {
+			return errors.Wrapf(err, "Suspend process group %v", pgid)
+		}
+		if frozen {
+			return nil
+		}
+	}
+	if err := signalGroup(ctx, pgid, members, lo.Ternary(opts.Terminal, unix.SIGTSTP, unix.SIGSTOP)); err != nil {
+		return errors.Wrapf(err, "Suspend process group %v", pgid)
+	}
+	return errors.Wrapf(waitForSuspendState(ctx, members, true), "Suspend process group %v", pgid)
+}
+
+// ResumeGroup is the same as ResumeGroupWithContext with background context.
+func ResumeGroup(pgid int) error {
+	return ResumeGroupWithContext(context.Background(), pgid)
+}
+
+// ResumeGroupWithContext continues all processes of the process group with ID `pgid` suspended by
+// SuspendGroupWithContext using context `ctx` and waits until they are running.
+func ResumeGroupWithContext(ctx context.Context, pgid int) error {
+	members, err := groupMembers(pgid)
+	if err != nil {
+		return errors.Wrapf(err, "Resume process group %v", pgid)
+	}
+	if _, err := freezeCgroup(ctx, members, false); err != nil {
+		return errors.Wrapf(err, "Resume process group %v", pgid)
+	}
+	if err := signalGroup(ctx, pgid, members, unix.SIGCONT); err != nil {
+		return errors.Wrapf(err, "Resume process group %v", pgid)
+	}
+	return errors.Wrapf(waitForSuspendState(ctx, members, false), "Resume process group %v", pgid)
+}
+
+// signalGroup sends signal `sig` to the process group with ID `pgid` and records it for each of `members` using context
+// `ctx`.
+func signalGroup(ctx context.Context, pgid int, members []int, sig unix.Signal) (err error) {
+	for _, pid := range members {
+		defer startAudit(ctx, pid, AuditRecord{Action: AuditSignal, Signal: sig})(&err)
+	}
+	return errors.Wrapf(unix.Kill(-pgid, sig), "Send signal %v to process group %v", sig, pgid)
+}
+
+// groupMembers returns PID's of all processes of the process group with ID `pgid`.
+func groupMembers(pgid int) ([]int, error) {
+	snap, err := NewProcSnapshot()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Get members of process group %v", pgid)
+	}
+	members := []int{}
+	for _, pid := range snap.Pids() {
+		if id, err := unix.Getpgid(pid); err == nil && id == pgid {
+			members = append(members, pid)
+		}
+	}
+	if len(members) == 0 {
+		return nil, errors.Newf("Get members of process group %v: Group not found", pgid)
+	}
+	return members, nil
+}
+
+// suspendProc sends SIGSTOP (or SIGTSTP if `opts.Terminal` is set) to the process with PID `pid` using context `ctx`.
+func suspendProc(ctx context.Context, pid int, opts SuspendOptions) (err error) {
+	sig := lo.Ternary(opts.Terminal, unix.SIGTSTP, unix.SIGSTOP)
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditSignal, Signal: sig})(&err)
+	return errors.Wrapf(unix.Kill(pid, sig), "Send signal %v to the process with PID %v", sig, pid)
+}
+
+// resumeProc sends SIGCONT to the process with PID `pid` using context `ctx`.
+func resumeProc(ctx context.Context, pid int) (err error) {
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditSignal, Signal: unix.SIGCONT})(&err)
+	return errors.Wrapf(unix.Kill(pid, unix.SIGCONT), "Send signal %v to the process with PID %v", unix.SIGCONT, pid)
+}
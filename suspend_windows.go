@@ -0,0 +1,43 @@
+//go:build windows
+
+package terminator
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	ntdll = windows.NewLazyDLL("ntdll.dll")
+)
+
+// suspendProc suspends all threads of the process with PID `pid` using NtSuspendProcess.
+func suspendProc(_ context.Context, pid int, _ SuspendOptions) error {
+	return callProcessFunc("NtSuspendProcess", pid)
+}
+
+// resumeProc resumes all threads of the process with PID `pid` using NtResumeProcess.
+func resumeProc(_ context.Context, pid int) error {
+	return callProcessFunc("NtResumeProcess", pid)
+}
+
+// callProcessFunc calls ntdll function with name `name` taking a process handle with PROCESS_SUSPEND_RESUME access for
+// the process with PID `pid`.
+func callProcessFunc(name string, pid int) error {
+	handle, err := windows.OpenProcess(windows.PROCESS_SUSPEND_RESUME, false, uint32(pid))
+	if err != nil {
+		if errors.Is(err, windows.ERROR_INVALID_PARAMETER) {
+			return newErrProcDied(pid)
+		}
+		return errors.Wrapf(err, "Call %v for the process with PID %v: Open process", name, pid)
+	}
+	defer windows.CloseHandle(handle)
+
+	status, _, _ := ntdll.NewProc(name).Call(uintptr(handle))
+	if status != 0 {
+		return errors.Newf("Call %v for the process with PID %v: NTSTATUS 0x%X", name, pid, status)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+//go:build linux
+
+package terminator
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"golang.org/x/sys/unix"
+)
+
+// termProc is a process attached to a terminal.
+type termProc struct {
+	pid  int
+	pgid int
+	sid  int
+	// tpgid is ID of the foreground process group of the terminal, or -1 if there is none.
+	tpgid int
+}
+
+// GetInputTerm returns TTY device of the process with PID `pid` as GetTerm, or if the process has no controlling
+// terminal (e.g. it was started with setsid), the pseudo terminal its standard input is attached to.
+func GetInputTerm(pid int) (string, error) {
+	term, err := GetTerm(pid)
+	if err == nil {
+		return term, nil
+	}
+	stdin, stdinErr := os.Readlink(procPath(strconv.Itoa(pid), "fd", "0"))
+	if stdinErr == nil && strings.HasPrefix(stdin, "/dev/pts/") {
+		return stdin, nil
+	}
+	return "", err
+}
+
+// GetTermProcs returns PID's of all processes with the TTY device `tty` (e.g. "/dev/pts/3", as returned by
+// GetInputTerm) as controlling terminal.
+func GetTermProcs(tty string) ([]int, error) {
+	procs, err := readTermProcs(tty)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Get processes of terminal %v", tty)
+	}
+	return lo.Map(procs, func(proc termProc, _ int) int { return proc.pid }), nil
+}
+
+// GetTermSessionLeader returns PID of the session leader of the TTY device `tty`, usually the shell the terminal was
+// opened with.
+func GetTermSessionLeader(tty string) (int, error) {
+	procs, err := readTermProcs(tty)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Get session leader of terminal %v", tty)
+	}
+	leader, ok := lo.Find(procs, func(proc termProc) bool { return proc.pid == proc.sid })
+	if !ok {
+		return 0, errors.Newf("Get session leader of terminal %v: Session leader not found", tty)
+	}
+	return leader.pid, nil
+}
+
+// GetTermForeground returns ID of the foreground process group of the TTY device `tty`, which is the job reading the
+// terminal input and receiving signals of Ctrl + C and Ctrl + Z.
+func GetTermForeground(tty string) (int, error) {
+	procs, err := readTermProcs(tty)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Get foreground process group of terminal %v", tty)
+	}
+	if procs[0].tpgid <= 0 {
+		return 0, errors.Newf("Get foreground process group of terminal %v: No foreground process group", tty)
+	}
+	return procs[0].tpgid, nil
+}
+
+// InterruptForeground is the same as InterruptForegroundWithContext with background context.
+func InterruptForeground(tty string) error {
+	return InterruptForegroundWithContext(context.Background(), tty)
+}
+
+// InterruptForegroundWithContext interrupts the foreground job of the TTY device `tty` (e.g. "/dev/pts/3", as returned
+// by GetInputTerm) using
+// context `ctx`, as pressing Ctrl + C in that terminal would: SIGINT is sent to all processes of the foreground process
+// group.
+//
+// If the terminal does not generate signals (e.g. it is in raw mode, as in full screen editors) or its interrupt
+// character is not Ctrl + C, the Ctrl + C character is written to the terminal input instead (and audited as
+// AuditMessage), which requires the same privilegies as SendMessageWithContext.
+func InterruptForegroundWithContext(ctx context.Context, tty string) (err error) {
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "Interrupt foreground job of terminal %v", tty)
+	default:
+	}
+
+	procs, err := readTermProcs(tty)
+	if err != nil {
+		return errors.Wrapf(err, "Interrupt foreground job of terminal %v", tty)
+	}
+	pgid := procs[0].tpgid
+	if pgid <= 0 {
+		return errors.Newf("Interrupt foreground job of terminal %v: No foreground process group", tty)
+	}
+	members := lo.FilterMap(procs, func(proc termProc, _ int) (int, bool) { return proc.pid, proc.pgid == pgid })
+	if ctx, err = checkGuard(ctx, "interrupt foreground", members); err != nil {
+		return errors.Wrapf(err, "Interrupt foreground job of terminal %v", tty)
+	}
+
+	if termios, termErr := readTermios(tty); termErr == nil {
+		if termios.Lflag&unix.ISIG == 0 || termios.Cc[unix.VINTR] != ctrlC {
+			// Ctrl + C is read by the foreground job as a regular character.
+			for _, pid := range members {
+				defer startAudit(ctx, pid, AuditRecord{Action: AuditMessage, Message: string(rune(ctrlC))})(&err)
+			}
+			if err := injectTermInput(tty, ctrlC); err != nil {
+				return errors.Wrapf(err, "Interrupt foreground job of terminal %v", tty)
+			}
+			return nil
+		}
+	}
+	for _, pid := range members {
+		defer startAudit(ctx, pid, AuditRecord{Action: AuditSignal, Signal: unix.SIGINT})(&err)
+	}
+	if err := unix.Kill(-pgid, unix.SIGINT); err != nil {
+		return errors.Wrapf(err, "Interrupt foreground job of terminal %v", tty)
+	}
+	return nil
+}
+
+// ctrlC is the character sent by terminals on Ctrl + C.
+const ctrlC = 0x03
+
+// readTermProcs returns the processes with the TTY device `tty` as controlling terminal, reading the proc filesystem
+// once. Returns error if there are none.
+func readTermProcs(tty string) ([]termProc, error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(tty, &stat); err != nil {
+		return nil, errors.Wrap(err, "Get terminal device number")
+	}
+	if stat.Mode&unix.S_IFMT != unix.S_IFCHR {
+		return nil, errors.Newf("%v is not a terminal device", tty)
+	}
+	// tty_nr field of /proc/<pid>/stat, see new_encode_dev in linux/kdev_t.h.
+	major, minor := unix.Major(stat.Rdev), unix.Minor(stat.Rdev)
+	ttyNr := strconv.FormatUint(uint64(minor&0xff|major<<8|(minor&^0xff)<<12), 10)
+
+	entries, err := os.ReadDir(procPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "List processes")
+	}
+	procs := []termProc{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(procPath(entry.Name(), "stat"))
+		if err != nil {
+			// The process exited during the scan.
+			continue
+		}
+		fields, ok := parseProcStat(content)
+		if !ok || len(fields) < 6 || string(fields[4]) != ttyNr {
+			continue
+		}
+		proc := termProc{pid: pid}
+		proc.pgid, _ = strconv.Atoi(string(fields[2]))
+		proc.sid, _ = strconv.Atoi(string(fields[3]))
+		proc.tpgid, _ = strconv.Atoi(string(fields[5]))
+		procs = append(procs, proc)
+	}
+	if len(procs) == 0 {
+		return nil, errors.Newf("No processes attached to terminal %v", tty)
+	}
+	return procs, nil
+}
+
+// readTermios returns the terminal attributes of the TTY device `tty`.
+func readTermios(tty string) (*unix.Termios, error) {
+	fd, err := unix.Open(tty, unix.O_RDONLY|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Open terminal %v", tty)
+	}
+	defer unix.Close(fd)
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Get attributes of terminal %v", tty)
+	}
+	return termios, nil
+}
+
+// injectTermInput inserts `char` into the input queue of the TTY device `tty`, as if it was typed.
+func injectTermInput(tty string, char byte) error {
+	file, err := os.OpenFile(tty, os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "Open terminal %v", tty)
+	}
+	defer file.Close()
+	if err := unix.IoctlSetPointerInt(int(file.Fd()), unix.TIOCSTI, int(char)); err != nil {
+		return errors.Wrapf(err, "Write to input of terminal %v", tty)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+//go:build !linux
+
+package terminator
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+)
+
+// GetInputTerm is only implemented on Linux, returns ErrNotSupported.
+func GetInputTerm(pid int) (string, error) {
+	return "", errors.Wrapf(ErrNotSupported, "Get input terminal of process with PID %v", pid)
+}
+
+// GetTermProcs is only implemented on Linux, returns ErrNotSupported.
+func GetTermProcs(tty string) ([]int, error) {
+	return nil, errors.Wrapf(ErrNotSupported, "Get processes of terminal %v", tty)
+}
+
+// GetTermSessionLeader is only implemented on Linux, returns ErrNotSupported.
+func GetTermSessionLeader(tty string) (int, error) {
+	return 0, errors.Wrapf(ErrNotSupported, "Get session leader of terminal %v", tty)
+}
+
+// GetTermForeground is only implemented on Linux, returns ErrNotSupported.
+func GetTermForeground(tty string) (int, error) {
+	return 0, errors.Wrapf(ErrNotSupported, "Get foreground process group of terminal %v", tty)
+}
+
+// InterruptForeground is the same as InterruptForegroundWithContext with background context.
+func InterruptForeground(tty string) error {
+	return InterruptForegroundWithContext(context.Background(), tty)
+}
+
+// InterruptForegroundWithContext is only implemented on Linux, returns ErrNotSupported.
+func InterruptForegroundWithContext(ctx context.Context, tty string) error {
+	return errors.Wrapf(ErrNotSupported, "Interrupt foreground job of terminal %v", tty)
+}
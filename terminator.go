@@ -3,24 +3,36 @@ package terminator
 import (
 	"context"
 	"fmt"
+	"os"
+	"syscall"
 	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
+// ErrNotSupported indicates that the operation is not supported on the current platform.
+var ErrNotSupported = errors.New("Not supported on this platform")
+
 // Kill is the same as KillWithContext with background context.
 func Kill(pid int) error {
 	return KillWithContext(context.Background(), pid)
 }
 
 // KillWithContext kills process with PID `pid` using context `ctx`.
-func KillWithContext(ctx context.Context, pid int) error {
+//
+// Among others, can return ErrGuardViolation error defined in this package, see GuardOptions.
+func KillWithContext(ctx context.Context, pid int) (err error) {
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditKill})(&err)
 	select {
 	case <-ctx.Done():
 		return errors.Wrapf(ctx.Err(), "Kill process with PID %v", pid)
 	default:
 	}
+	if _, err := checkGuard(ctx, "kill", []int{pid}); err != nil {
+		return errors.Wrapf(err, "Kill process with PID %v", pid)
+	}
 
 	proc, err := process.NewProcess(int32(pid))
 	if err != nil {
@@ -29,72 +41,187 @@ func KillWithContext(ctx context.Context, pid int) error {
 	return errors.Wrap(proc.Kill(), fmt.Sprintf("Kill process with PID %v", pid))
 }
 
+// KillTree is the same as KillTreeWithContext with background context.
+func KillTree(pid int, withRoot bool) error {
+	return KillTreeWithContext(context.Background(), pid, withRoot)
+}
+
+// KillTreeWithContext kills all descendants of the process with PID `pid` using context `ctx`, deepest first.
+//
+// If the `withRoot` argument is set to true, kill the root process last.
+//
+// If the process event stream is available (see ListenProcEvents), descendants spawned during the operation are killed
+// as well, for up to 2 seconds after the tree was killed, as a root which is not killed may keep forking.
+//
+// The guards (see GuardOptions) are checked for the whole tree before killing anything.
+func KillTreeWithContext(ctx context.Context, pid int, withRoot bool) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "Kill process tree of PID %v", pid)
+	default:
+	}
+
+	// Subscribe before collecting the tree to not to miss children forked in between.
+	listener, _ := shareProcEvents(ctx)
+	if listener != nil {
+		defer listener.Close()
+	}
+
+	// Processes whose new children must be killed. The root is tracked even if it is not killed itself.
+	tracked := map[int]bool{pid: true}
+	var result error
+	killTree := func() error {
+		tree, err := FlatChildTree(pid, withRoot)
+		if err != nil {
+			return errors.Wrapf(err, "Kill process tree of PID %v", pid)
+		}
+		pids := lo.Map(tree, func(proc *process.Process, _ int) int { return int(proc.Pid) })
+		if ctx, err = checkGuard(ctx, "kill tree", pids); err != nil {
+			return errors.Wrapf(err, "Kill process tree of PID %v", pid)
+		}
+		for _, proc := range tree {
+			tracked[int(proc.Pid)] = true
+			if err := KillWithContext(ctx, int(proc.Pid)); err != nil && !isProcGone(err) {
+				result = errors.CombineErrors(result, errors.Wrapf(err, "Kill process tree of PID %v", pid))
+			}
+		}
+		return nil
+	}
+	if err := killTree(); err != nil {
+		return err
+	}
+	if listener == nil {
+		return result
+	}
+
+	// Kill descendants forked while the tree was being killed, until no new ones appear for a while. The time is
+	// limited, as a root which is not killed can fork forever.
+	settle := time.NewTimer(treeSettleTime)
+	defer settle.Stop()
+	limit := time.NewTimer(treeSettleLimit)
+	defer limit.Stop()
+	for {
+		select {
+		case event, ok := <-listener.Events():
+			if !ok {
+				return result
+			}
+			switch {
+			case event.Kind == ProcEventOverflow:
+				// Some forks may be lost, collect the tree again.
+				if err := killTree(); err != nil {
+					return errors.CombineErrors(result, err)
+				}
+			case event.Kind == ProcEventFork && !event.IsThread() && tracked[event.ParentPID]:
+				tracked[event.PID] = true
+				if err := KillWithContext(ctx, event.PID); err != nil && !isProcGone(err) {
+					result = errors.CombineErrors(result, errors.Wrapf(err, "Kill process tree of PID %v", pid))
+				}
+			default:
+				continue
+			}
+			settle.Reset(treeSettleTime)
+		case <-settle.C:
+			return result
+		case <-limit.C:
+			return result
+		case <-ctx.Done():
+			return errors.CombineErrors(result, errors.Wrapf(ctx.Err(), "Kill process tree of PID %v", pid))
+		}
+	}
+}
+
+// treeSettleTime is how long KillTreeWithContext waits for new descendants to appear after the tree was killed.
+const treeSettleTime = time.Millisecond * 100
+
+// treeSettleLimit is the maximum time KillTreeWithContext tracks new descendants after the tree was killed.
+const treeSettleLimit = time.Second * 2
+
+// isProcGone returns true if `err` indicates the process does not exist (anymore).
+func isProcGone(err error) bool {
+	return errors.Is(err, process.ErrorProcessNotRunning) || errors.Is(err, os.ErrProcessDone) ||
+		errors.Is(err, syscall.ESRCH) || isProcDied(err)
+}
+
 // WaitForProcStop returns when process with PID `pid` is no longer running or `ctx` deadline exceedes.
+//
+// Zombie processes are considered not running.
+//
+// If the process event stream is available (see ListenProcEvents), returns as soon as the process exits instead of
+// polling.
 func WaitForProcStop(ctx context.Context, pid int) {
+	_ = waitForProcStop(ctx, pid)
+}
+
+// waitForProcStop returns true when process with PID `pid` is no longer running or false if `ctx` deadline exceedes
+// first.
+func waitForProcStop(ctx context.Context, pid int) bool {
 	proc, err := process.NewProcess(int32(pid))
 	if err != nil {
-		return
+		return true
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	exited := procExitNotify(ctx, pid)
+
 	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
 	for {
 		select {
+		case <-exited:
+			if procStopped(proc) {
+				return true
+			}
 		case <-ticker.C:
-			if running, _ := proc.IsRunning(); !running {
-				return
+			if procStopped(proc) {
+				return true
 			}
 		case <-ctx.Done():
-			return
+			return false
 		}
 	}
 }
 
+// procStopped returns true if the process `proc` is no longer running or is a zombie.
+func procStopped(proc *process.Process) bool {
+	if running, _ := proc.IsRunning(); !running {
+		return true
+	}
+	return isZombie(int(proc.Pid))
+}
+
 // FlatChildTree returns gopsutil Process instances of all descendants of a process with the specified PID `pid`.
 //
 // The first element is deepest descendant. The last one is a progenitor or closest child.
 //
 // If the `withRoot` argument is set to true, add root process to the end.
+//
+// Processes are enumerated once, see ProcSnapshot.
 func FlatChildTree(pid int, withRoot bool) ([]*process.Process, error) {
 	tree := []*process.Process{}
 	proc, err := process.NewProcess(int32(pid))
 	if err != nil {
 		return tree, errors.Wrap(err, "Get flat child process tree")
 	}
-	err = flatChildTree(proc, &tree, withRoot)
+	snap, err := NewProcSnapshot()
 	if err != nil {
 		return tree, errors.Wrap(err, "Get flat child process tree")
 	}
-	return tree, nil
-}
-
-// flatChildTree populates the `tree` argument with gopsutil Process instances of all descendants of the specified
-// process `proc`.
-//
-// The first element in the tree is deepest descendant. The last one is a progenitor or closest child.
-//
-// If the `withRoot` argument is set to true, add the root process to the end.
-func flatChildTree(proc *process.Process, tree *[]*process.Process, withRoot bool) error {
-	children, err := proc.Children()
-	if errors.Is(err, process.ErrorNoChildren) {
-		return nil
-	}
-	if err != nil {
-		return errors.Wrapf(err, "Get flat process tree for PID %v", proc.Pid)
-	}
-	// Iterate for each child process in reverse order.
-	for i := len(children) - 1; i >= 0; i-- {
-		child := children[i]
-		// Call self to collect descendants.
-		err := flatChildTree(child, tree, false)
+	for _, childPid := range snap.FlatChildTree(pid, false) {
+		child, err := process.NewProcess(int32(childPid))
+		if errors.Is(err, process.ErrorProcessNotRunning) {
+			// The process exited after the snapshot was taken.
+			continue
+		}
 		if err != nil {
-			return err
+			return tree, errors.Wrap(err, "Get flat child process tree")
 		}
-		// Add the child after it's descendants.
-		*tree = append(*tree, child)
+		tree = append(tree, child)
 	}
 	// Add the root process to the end.
 	if withRoot {
-		*tree = append(*tree, proc)
+		tree = append(tree, proc)
 	}
-	return nil
+	return tree, nil
 }
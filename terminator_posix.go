@@ -12,18 +12,28 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// DefaultAutoSignals is a list of candidate signals for ActionAutoSignal stage if Stage.Signals is empty.
+var DefaultAutoSignals = []syscall.Signal{syscall.SIGINT, syscall.SIGTERM}
+
 // SendSignal is the same as SendSignalWithContext with background context.
 func SendSignal(pid int, sig syscall.Signal) error {
 	return SendSignalWithContext(context.Background(), pid, sig)
 }
 
 // SendSignalWithContext sends signal `sig` to the process with PID `pid` using context `ctx`.
-func SendSignalWithContext(ctx context.Context, pid int, sig syscall.Signal) error {
+func SendSignalWithContext(ctx context.Context, pid int, sig syscall.Signal) (err error) {
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditSignal, Signal: sig})(&err)
 	select {
 	case <-ctx.Done():
 		return errors.Wrapf(ctx.Err(), "Send signal %v to the process with PID %v", sig, pid)
 	default:
 	}
+	// Signal 0 only checks whether the process exists.
+	if sig != 0 {
+		if _, err := checkGuard(ctx, "signal", []int{pid}); err != nil {
+			return errors.Wrapf(err, "Send signal %v to the process with PID %v", sig, pid)
+		}
+	}
 
 	proc, err := process.NewProcess(int32(pid))
 	if err != nil {
@@ -45,12 +55,16 @@ func SendMessage(pid int, msg string) error {
 // `msg` must end with "\n" on Linux and with "\r" on macOS to be sent.
 //
 // Requires root privilegies (e.g. run as sudo).
-func SendMessageWithContext(ctx context.Context, pid int, msg string) error {
+func SendMessageWithContext(ctx context.Context, pid int, msg string) (err error) {
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditMessage, Message: msg})(&err)
 	select {
 	case <-ctx.Done():
 		return errors.Wrapf(ctx.Err(), "Write message to stdin of the process with PID %v", pid)
 	default:
 	}
+	if _, err := checkGuard(ctx, "message", []int{pid}); err != nil {
+		return errors.Wrapf(err, "Write message to stdin of the process with PID %v", pid)
+	}
 
 	term, err := GetTerm(pid)
 	if err != nil {
@@ -68,3 +82,8 @@ func SendMessageWithContext(ctx context.Context, pid int, msg string) error {
 	}
 	return nil
 }
+
+// isProcDied returns false as ErrProcDied is only returned on Windows.
+func isProcDied(err error) bool {
+	return false
+}
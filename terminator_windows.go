@@ -42,6 +42,11 @@ func newErrProcDied(pid int) ErrProcDied {
 	return ErrProcDied{PID: pid}
 }
 
+// isProcDied returns true if `err` is ErrProcDied.
+func isProcDied(err error) bool {
+	return errors.HasType(err, ErrProcDied{})
+}
+
 // ErrBadExitCode indicates that process exited with unexpected exit code.
 type ErrBadExitCode struct {
 	Code     int
@@ -58,6 +63,9 @@ func newErrBadExitCode(code int, procName string) ErrBadExitCode {
 	return ErrBadExitCode{Code: code, ProcName: procName}
 }
 
+// DefaultAutoSignals is a list of candidate signals for ActionAutoSignal stage if Stage.Signals is empty.
+var DefaultAutoSignals = []syscall.Signal{windows.CTRL_C_EVENT, windows.CTRL_BREAK_EVENT}
+
 // SendSignal is the same as SendSignalWithContext with background context.
 func SendSignal(pid int, sig syscall.Signal) error {
 	return SendSignalWithContext(context.Background(), pid, sig)
@@ -75,12 +83,16 @@ func SendSignal(pid int, sig syscall.Signal) error {
 // CTRL_C_EVENT and CTRL_BREAK_EVENT can be caught as SIGINT.
 //
 // Inspired by https://stackoverflow.com/a/15281070, https://stackoverflow.com/a/2445728.
-func SendSignalWithContext(ctx context.Context, pid int, sig syscall.Signal) error {
+func SendSignalWithContext(ctx context.Context, pid int, sig syscall.Signal) (err error) {
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditSignal, Signal: sig})(&err)
 	select {
 	case <-ctx.Done():
 		return errors.Wrapf(ctx.Err(), "Send signal %v to the process with PID %v", sig, pid)
 	default:
 	}
+	if _, err := checkGuard(ctx, "signal", []int{pid}); err != nil {
+		return errors.Wrapf(err, "Send signal %v to the process with PID %v", sig, pid)
+	}
 
 	const NULL uintptr = 0
 	const TRUE uintptr = 1
@@ -151,12 +163,16 @@ func SendMessage(pid int, msg string) error {
 // SendMessageWithContext writes a `msg` message to the console process with PID `pid` using context `ctx`.
 //
 // `msg` must end with "\r\n" to be sent.
-func SendMessageWithContext(ctx context.Context, pid int, msg string) error {
+func SendMessageWithContext(ctx context.Context, pid int, msg string) (err error) {
+	defer startAudit(ctx, pid, AuditRecord{Action: AuditMessage, Message: msg})(&err)
 	select {
 	case <-ctx.Done():
 		return errors.Wrapf(ctx.Err(), "Failed to send message to process with PID %v", pid)
 	default:
 	}
+	if _, err := checkGuard(ctx, "message", []int{pid}); err != nil {
+		return errors.Wrapf(err, "Failed to send message to process with PID %v", pid)
+	}
 
 	proxyPath, err := getProxyPath()
 	if err != nil {
@@ -192,12 +208,17 @@ func CloseWindow(wnd w32.HWND, wait bool) error {
 // the window was actually closed.
 //
 // Can be caught as SIGTERM.
-func CloseWindowWithContext(ctx context.Context, wnd w32.HWND, wait bool) error {
+func CloseWindowWithContext(ctx context.Context, wnd w32.HWND, wait bool) (err error) {
+	_, pid := w32.GetWindowThreadProcessId(wnd)
+	defer startAudit(ctx, int(pid), AuditRecord{Action: AuditCloseWindow, Window: uintptr(wnd)})(&err)
 	select {
 	case <-ctx.Done():
 		return errors.Wrapf(ctx.Err(), "Failed to send close message to window with handle %v", wnd)
 	default:
 	}
+	if _, err := checkGuard(ctx, "close window", []int{int(pid)}); err != nil {
+		return errors.Wrapf(err, "Failed to send close message to window with handle %v", wnd)
+	}
 
 	var ok bool
 	message := lo.Ternary(IsUWPAppWindow(wnd), w32.WM_QUIT, w32.WM_CLOSE)
@@ -0,0 +1,11 @@
+//go:build !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package terminator
+
+import "golang.org/x/sys/unix"
+
+// Requests of ioctl(2) to get and set terminal attributes.
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
@@ -0,0 +1,8 @@
+package terminator
+
+// TermState is a snapshot of the settings of a terminal, see SaveTermState.
+type TermState struct {
+	// Path is the TTY device the settings were read from.
+	Path     string
+	settings termSettings
+}
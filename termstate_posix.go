@@ -0,0 +1,122 @@
+//go:build !windows
+
+package terminator
+
+import (
+	"github.com/cockroachdb/errors"
+	"golang.org/x/sys/unix"
+)
+
+// termResetSequence leaves the alternate screen, shows the cursor and disables bracketed paste mode.
+const termResetSequence = "\x1b[?1049l\x1b[?25h\x1b[?2004l"
+
+// termSettings are saved settings of a terminal.
+type termSettings struct {
+	termios *unix.Termios
+	winsize *unix.Winsize
+}
+
+// SaveTermState returns a snapshot of the attributes (termios) and the window size of the TTY device `tty`, or of the
+// controlling terminal of the current process if empty.
+//
+// Take the snapshot before starting a program which changes the terminal settings, such as an editor or a pager, to
+// restore them with TermState.Restore or Policy.Terminal if the program is killed.
+func SaveTermState(tty string) (*TermState, error) {
+	if tty == "" {
+		tty = "/dev/tty"
+	}
+	fd, err := openTerm(tty)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Save state of terminal %v", tty)
+	}
+	defer unix.Close(fd)
+	state := &TermState{Path: tty}
+	if state.settings.termios, err = unix.IoctlGetTermios(fd, ioctlGetTermios); err != nil {
+		return nil, errors.Wrapf(err, "Save state of terminal %v: Get attributes", tty)
+	}
+	if state.settings.winsize, err = unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ); err != nil {
+		return nil, errors.Wrapf(err, "Save state of terminal %v: Get window size", tty)
+	}
+	return state, nil
+}
+
+// Restore sets the attributes and the window size of the terminal back to the saved ones, e.g. turns echo and line
+// editing back on after a program left the terminal in raw mode.
+//
+// Should be called from the foreground process group of the terminal, otherwise the current process is stopped with
+// SIGTTOU, unless the signal is ignored.
+func (s *TermState) Restore() error {
+	fd, err := openTerm(s.Path)
+	if err != nil {
+		return errors.Wrapf(err, "Restore state of terminal %v", s.Path)
+	}
+	defer unix.Close(fd)
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, s.settings.termios); err != nil {
+		return errors.Wrapf(err, "Restore state of terminal %v: Set attributes", s.Path)
+	}
+	if err := unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, s.settings.winsize); err != nil {
+		return errors.Wrapf(err, "Restore state of terminal %v: Set window size", s.Path)
+	}
+	return nil
+}
+
+// ResetScreen writes the escape sequences leaving the alternate screen, showing the cursor and disabling bracketed
+// paste mode to the terminal, undoing what full screen programs such as editors and pagers do on start.
+func (s *TermState) ResetScreen() error {
+	fd, err := openTerm(s.Path)
+	if err != nil {
+		return errors.Wrapf(err, "Reset screen of terminal %v", s.Path)
+	}
+	defer unix.Close(fd)
+	if _, err := unix.Write(fd, []byte(termResetSequence)); err != nil {
+		return errors.Wrapf(err, "Reset screen of terminal %v", s.Path)
+	}
+	return nil
+}
+
+// openTerm opens the TTY device `tty` without making it the controlling terminal.
+func openTerm(tty string) (int, error) {
+	fd, err := unix.Open(tty, unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, errors.Wrapf(err, "Open terminal %v", tty)
+	}
+	return fd, nil
+}
+
+// restoreTermAfter returns a function restoring the terminal to `state` after the process with PID `pid` is stopped,
+// which also resets the screen if the process is in the foreground of the terminal when the stop starts.
+//
+// The terminal is not restored if it is the controlling terminal of the current process, but the current process is
+// not in it's foreground, as it would be stopped with SIGTTOU. Errors are ignored, as the terminal may be gone along
+// with the process.
+func restoreTermAfter(state *TermState, pid int) func() {
+	foreground, err := termForeground(state.Path)
+	pgid, pgidErr := unix.Getpgid(pid)
+	wasForeground := err == nil && pgidErr == nil && pgid == foreground
+	return func() {
+		if foreground, err := termForeground(state.Path); err == nil {
+			if self, err := unix.Getpgid(0); err != nil || self != foreground {
+				return
+			}
+		}
+		_ = state.Restore()
+		if wasForeground {
+			_ = state.ResetScreen()
+		}
+	}
+}
+
+// termForeground returns ID of the foreground process group of the TTY device `tty`, which must be the controlling
+// terminal of the current process.
+func termForeground(tty string) (int, error) {
+	fd, err := openTerm(tty)
+	if err != nil {
+		return 0, err
+	}
+	defer unix.Close(fd)
+	pgid, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Get foreground process group of terminal %v", tty)
+	}
+	return pgid, nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package terminator
+
+import (
+	"github.com/cockroachdb/errors"
+)
+
+// termSettings are saved settings of a terminal.
+type termSettings struct{}
+
+// SaveTermState is only implemented on POSIX, returns ErrNotSupported.
+func SaveTermState(tty string) (*TermState, error) {
+	return nil, errors.Wrapf(ErrNotSupported, "Save state of terminal %v", tty)
+}
+
+// Restore is only implemented on POSIX, returns ErrNotSupported.
+func (s *TermState) Restore() error {
+	return errors.Wrapf(ErrNotSupported, "Restore state of terminal %v", s.Path)
+}
+
+// ResetScreen is only implemented on POSIX, returns ErrNotSupported.
+func (s *TermState) ResetScreen() error {
+	return errors.Wrapf(ErrNotSupported, "Reset screen of terminal %v", s.Path)
+}
+
+// restoreTermAfter returns a function doing nothing, as terminal settings are only restored on POSIX.
+func restoreTermAfter(state *TermState, pid int) func() {
+	return func() {}
+}